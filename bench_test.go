@@ -70,6 +70,59 @@ func BenchmarkMapInterfaceEncoder(b *testing.B) {
 	})
 }
 
+func BenchmarkAppendEncode(b *testing.B) {
+	m := hcler.Map{
+		"name":  "web",
+		"count": 3,
+		"ratio": 0.5,
+		"tags":  hcler.List{"a", "b", "c"},
+		"limits": hcler.Map{
+			"cpu":    500,
+			"memory": int64(256),
+		},
+	}
+	b.Run("encode", func(b *testing.B) {
+		b.ReportAllocs()
+		run(b, m)
+	})
+	b.Run("append_encode", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := make([]byte, 0, 1024)
+		for i := 0; i < b.N; i++ {
+			var err error
+			if buf, err = hcler.AppendEncode(buf[:0], m); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type benchJob struct {
+	Name   string            `hcl:"name"`
+	Count  int               `hcl:"count"`
+	Tags   []string          `hcl:"tags"`
+	Meta   map[string]string `hcl:"meta"`
+	Driver *benchDriver      `hcl:"driver"`
+}
+
+type benchDriver struct {
+	Image string `hcl:"image"`
+	Ports []int  `hcl:"ports"`
+}
+
+func BenchmarkStructEncoder(b *testing.B) {
+	b.Run("struct", func(b *testing.B) {
+		m := benchJob{
+			Name:   "web",
+			Count:  3,
+			Tags:   []string{"a", "b"},
+			Meta:   map[string]string{"owner": "core"},
+			Driver: &benchDriver{Image: "nginx", Ports: []int{80, 443}},
+		}
+		run(b, m)
+	})
+}
+
 // Bellow, alternative map encoders considered. Discarded but kept for reference & bench.
 
 func BenchmarkDiscarded(b *testing.B) {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/creack/hcler"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// literal is a decoded scalar encoded as a HCL literal: strings escaped,
+// bools as true/false, null as null and numbers with full precision.
+type literal struct {
+	v cty.Value
+}
+
+// EncodeHCL implements hcler.Encoder.
+func (l literal) EncodeHCL() (string, error) {
+	return string(hclwrite.TokensForValue(l.v).Bytes()), nil
+}
+
+// literals rewrites the scalars of the given decoded document as
+// literals, leaving maps and lists for hcler to encode.
+func literals(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case hcler.OrderedMap:
+		out := make(hcler.OrderedMap, 0, len(v))
+		for _, kv := range v {
+			val, err := literals(kv.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "convert %q", kv.Key)
+			}
+			out = append(out, hcler.KeyValue{Key: kv.Key, Value: val})
+		}
+		return out, nil
+	case hcler.Map:
+		return literals(map[string]interface{}(v))
+	case map[string]interface{}:
+		out := make(hcler.Map, len(v))
+		for k, elem := range v {
+			val, err := literals(elem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "convert %q", k)
+			}
+			out[k] = val
+		}
+		return out, nil
+	case map[interface{}]interface{}:
+		// Keep the keys as is for the collision policy.
+		out := make(map[interface{}]interface{}, len(v))
+		for k, elem := range v {
+			val, err := literals(elem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "convert %v", k)
+			}
+			out[k] = val
+		}
+		return out, nil
+	case hcler.List:
+		return literals([]interface{}(v))
+	case []interface{}:
+		out := make(hcler.List, 0, len(v))
+		for _, elem := range v {
+			val, err := literals(elem)
+			if err != nil {
+				return nil, errors.Wrap(err, "convert list element")
+			}
+			out = append(out, val)
+		}
+		return out, nil
+	}
+
+	// Typed collections, i.e. TOML arrays of tables.
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		l := make([]interface{}, rv.Len())
+		for i := range l {
+			l[i] = rv.Index(i).Interface()
+		}
+		return literals(l)
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]interface{}, rv.Len())
+			for it := rv.MapRange(); it.Next(); {
+				m[it.Key().String()] = it.Value().Interface()
+			}
+			return literals(m)
+		}
+	}
+	val, err := hcler.ToCty(v)
+	if err != nil {
+		return nil, err
+	}
+	return literal{v: val}, nil
+}
@@ -0,0 +1,161 @@
+// Command hcler converts JSON, YAML or TOML documents to HCL.
+//
+// Usage:
+//
+//	hcler [-from json|yaml|toml] [-pretty] [-sort] [-order] [-body] [-collisions error|first|last] [file ...]
+//
+// Without file, the document is read from stdin.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "hcler: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// config holds the command line flags.
+type config struct {
+	from   string
+	pretty bool
+	sort   bool
+	order  bool
+	body   bool
+
+	collisions hcler.KeyCollisionPolicy
+}
+
+// collisionPolicies maps the -collisions flag values to policies.
+var collisionPolicies = map[string]hcler.KeyCollisionPolicy{
+	"error": hcler.CollisionError,
+	"first": hcler.CollisionFirstWins,
+	"last":  hcler.CollisionLastWins,
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	var cfg config
+	fs := flag.NewFlagSet("hcler", flag.ContinueOnError)
+	fs.StringVar(&cfg.from, "from", "", "input format: json, yaml or toml (default: from the file extension, json for stdin)")
+	fs.BoolVar(&cfg.pretty, "pretty", false, "pretty print objects and lists over multiple lines")
+	fs.BoolVar(&cfg.sort, "sort", false, "sort keys")
+	fs.BoolVar(&cfg.order, "order", false, "preserve the key order of json and yaml documents")
+	fs.BoolVar(&cfg.body, "body", false, "output a document body (attributes) instead of an object")
+	collisions := fs.String("collisions", "error", "how to handle YAML keys converting to the same string, i.e. 1 and \"1\": error, first or last")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	policy, ok := collisionPolicies[*collisions]
+	if !ok {
+		return errors.Errorf("unknown collision policy %q", *collisions)
+	}
+	cfg.collisions = policy
+
+	if fs.NArg() == 0 {
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return errors.Wrap(err, "read stdin")
+		}
+		return convert(stdout, cfg, "", data)
+	}
+	for _, name := range fs.Args() {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := convert(stdout, cfg, name, data); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+	return nil
+}
+
+// convert decodes the given document and writes it as HCL.
+func convert(w io.Writer, cfg config, name string, data []byte) error {
+	format := cfg.from
+	if format == "" {
+		format = formatFromName(name)
+	}
+	v, err := decode(cfg, format, data)
+	if err != nil {
+		return err
+	}
+	if v, err = literals(v); err != nil {
+		return err
+	}
+
+	var out string
+	opts := []hcler.Option{hcler.KeyCollisions(cfg.collisions)}
+	if cfg.body {
+		out, err = hcler.EncodeBody(v, opts...)
+	} else {
+		if cfg.sort {
+			opts = append(opts, hcler.SortKeys())
+		}
+		if cfg.pretty {
+			opts = append(opts, hcler.Indent("  "))
+		}
+		out, err = hcler.Encode(v, opts...)
+		out += "\n"
+	}
+	if err != nil {
+		return errors.Wrap(err, "encode")
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
+	}
+}
+
+// decode the given document. YAML mappings are decoded as
+// map[interface{}]interface{} and encoded through hcler.IMap.
+// With the order flag, JSON objects and YAML mappings are decoded
+// as hcler.OrderedMap.
+func decode(cfg config, format string, data []byte) (interface{}, error) {
+	switch format {
+	case "json":
+		return hcler.DecodeJSON(data, cfg.order)
+	case "yaml":
+		if cfg.order {
+			return decodeOrderedYAML(data, cfg.collisions)
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errors.Wrap(err, "decode yaml")
+		}
+		return v, nil
+	case "toml":
+		var m map[string]interface{}
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, errors.Wrap(err, "decode toml")
+		}
+		return m, nil
+	default:
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+}
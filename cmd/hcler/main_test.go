@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func assertRun(t *testing.T, expect, stdin string, args ...string) {
+	t.Helper()
+
+	var out bytes.Buffer
+	require.NoError(t, run(args, strings.NewReader(stdin), &out))
+	assert.Equal(t, expect, out.String())
+}
+
+func TestRun(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		assertRun(t, `{ a = [ 1, 2.5 ], b = { c = "d" } }`+"\n", `{"b": {"c": "d"}, "a": [1, 2.5]}`, "-sort")
+	})
+	t.Run("yaml", func(t *testing.T) {
+		assertRun(t, `{ a = [ 1, "x" ], b = { "1" = "one" } }`+"\n", "b:\n  1: one\na: [1, x]\n", "-from", "yaml", "-sort")
+	})
+	t.Run("toml", func(t *testing.T) {
+		assertRun(t, `{ a = 1, b = { c = "d" } }`+"\n", "a = 1\n[b]\nc = \"d\"\n", "-from", "toml", "-sort")
+	})
+	t.Run("pretty", func(t *testing.T) {
+		assertRun(t, "{\n  a = [\n    1,\n  ]\n}\n", `{"a": [1]}`, "-pretty")
+	})
+	t.Run("collisions", func(t *testing.T) {
+		assertRun(t, `{ "1" = "b" }`+"\n", "1: a\n\"1\": b\n", "-from", "yaml", "-collisions", "last")
+	})
+	t.Run("order", func(t *testing.T) {
+		assertRun(t, `{ source = "./m", version = 1, providers = { b = "x", a = "y" } }`+"\n", `{"source": "./m", "version": 1, "providers": {"b": "x", "a": "y"}}`, "-order")
+		assertRun(t, `{ source = "./m", version = 1, providers = { b = "x", "1" = "z" } }`+"\n", "source: ./m\nversion: 1\nproviders:\n  b: x\n  1: z\n", "-order", "-from", "yaml")
+		assertRun(t, `{ "1" = "a" }`+"\n", "1: a\n\"1\": b\n", "-order", "-from", "yaml", "-collisions", "first")
+	})
+	t.Run("body", func(t *testing.T) {
+		assertRun(t, "a = 1\nb = {\n  c = \"d\"\n}\n", `{"b": {"c": "d"}, "a": 1}`, "-body")
+	})
+}
+
+func TestRunRoundTrip(t *testing.T) {
+	expect := cty.ObjectVal(map[string]cty.Value{
+		"enabled": cty.True,
+		"x":       cty.NullVal(cty.DynamicPseudoType),
+		"f":       cty.NumberFloatVal(0.125),
+		"s":       cty.StringVal("a\"b\\c\n${d}"),
+		"l":       cty.TupleVal([]cty.Value{cty.False, cty.NumberIntVal(1)}),
+	})
+	for name, tc := range map[string]struct {
+		doc  string
+		args []string
+	}{
+		"json": {doc: `{"enabled": true, "x": null, "f": 0.125, "s": "a\"b\\c\n${d}", "l": [false, 1]}`},
+		"yaml": {doc: "enabled: true\nx: null\nf: 0.125\ns: \"a\\\"b\\\\c\\n${d}\"\nl: [false, 1]\n", args: []string{"-from", "yaml"}},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			for _, args := range [][]string{nil, {"-pretty"}, {"-order"}} {
+				var out bytes.Buffer
+				require.NoError(t, run(append(args, tc.args...), strings.NewReader(tc.doc), &out))
+				e, diags := hclsyntax.ParseExpression(out.Bytes(), "out.hcl", hcl.InitialPos)
+				require.False(t, diags.HasErrors(), "%s: %s", out.String(), diags.Error())
+				v, diags := e.Value(nil)
+				require.False(t, diags.HasErrors(), diags.Error())
+				assert.True(t, expect.RawEquals(v), "%s", out.String())
+			}
+
+			var out bytes.Buffer
+			require.NoError(t, run(append([]string{"-body"}, tc.args...), strings.NewReader(tc.doc), &out))
+			f, diags := hclsyntax.ParseConfig(out.Bytes(), "out.hcl", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), "%s: %s", out.String(), diags.Error())
+			attrs, diags := f.Body.JustAttributes()
+			require.False(t, diags.HasErrors(), diags.Error())
+			for name, attr := range attrs {
+				v, diags := attr.Expr.Value(nil)
+				require.False(t, diags.HasErrors(), diags.Error())
+				assert.True(t, expect.GetAttr(name).RawEquals(v), "%s: %#v", name, v)
+			}
+			assert.Len(t, attrs, 5)
+		})
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hcler")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	files := map[string]string{
+		"a.json": `{"a": 1}`,
+		"b.yml":  "b: 2\n",
+		"c.toml": "c = 3\n",
+	}
+	var names []string
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	for _, name := range []string{"a.json", "b.yml", "c.toml"} {
+		names = append(names, filepath.Join(dir, name))
+	}
+	assertRun(t, "{ a = 1 }\n{ b = 2 }\n{ c = 3 }\n", "", names...)
+}
+
+func TestRunError(t *testing.T) {
+	for name, args := range map[string][]string{
+		"format":     {"-from", "xml"},
+		"flag":       {"-unknown"},
+		"file":       {"does-not-exist.json"},
+		"json":       {"-from", "json"},
+		"body_type":  {"-body"},
+		"policy":     {"-collisions", "random"},
+		"order_yaml": {"-order", "-from", "yaml"},
+	} {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			stdin := "{"
+			if name == "body_type" {
+				stdin = "[1]"
+			}
+			require.Error(t, run(args, strings.NewReader(stdin), ioutil.Discard))
+		})
+	}
+}
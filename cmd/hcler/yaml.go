@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// decodeOrderedYAML decodes the given YAML document, which must be
+// a mapping, as a tree of hcler.OrderedMap.
+func decodeOrderedYAML(data []byte, policy hcler.KeyCollisionPolicy) (interface{}, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrap(err, "decode yaml")
+	}
+	return fromYAML(m, policy)
+}
+
+// fromYAML converts the yaml.MapSlice values of the given tree
+// to hcler.OrderedMap.
+func fromYAML(v interface{}, policy hcler.KeyCollisionPolicy) (interface{}, error) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		out := make(hcler.OrderedMap, 0, len(v))
+		for _, item := range v {
+			k, err := keyString(item.Key)
+			if err != nil {
+				return nil, err
+			}
+			val, err := fromYAML(item.Value, policy)
+			if err != nil {
+				return nil, errors.Wrapf(err, "convert %q", k)
+			}
+			if _, exists := out.Get(k); exists {
+				switch policy {
+				case hcler.CollisionFirstWins:
+					continue
+				case hcler.CollisionError:
+					return nil, errors.Wrapf(hcler.ErrKeyCollision, "%#v (%T) converts to the existing key %q", item.Key, item.Key, k)
+				}
+			}
+			out.Set(k, val)
+		}
+		return out, nil
+	case []interface{}:
+		out := make(hcler.List, 0, len(v))
+		for _, elem := range v {
+			val, err := fromYAML(elem, policy)
+			if err != nil {
+				return nil, errors.Wrap(err, "convert list element")
+			}
+			out = append(out, val)
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// keyString stringifies the given YAML key the same way hcler.IMap does.
+func keyString(k interface{}) (string, error) {
+	m, err := hcler.IMap{k: nil}.Map()
+	if err != nil {
+		return "", err
+	}
+	for s := range m {
+		return s, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,147 @@
+package hcler
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrKeyCollision is returned when distinct IMap keys convert to the
+// same string, i.e. 1 and "1".
+var ErrKeyCollision = errors.New("key collision")
+
+// KeyCollisionPolicy tells how to handle distinct IMap keys converting
+// to the same string.
+//
+// As map iteration order is random, colliding keys are ordered by type
+// name, then by value, to decide which one is first.
+type KeyCollisionPolicy int
+
+// Key collision policies.
+const (
+	CollisionError     KeyCollisionPolicy = iota // Fail with ErrKeyCollision.
+	CollisionFirstWins                           // Keep the value of the first key.
+	CollisionLastWins                            // Keep the value of the last key.
+)
+
+// MapWith converts an hcl.IMap to a hcl.Map using the given collision policy.
+func (m IMap) MapWith(policy KeyCollisionPolicy) (Map, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return stringifyKeys(m, policy)
+}
+
+// hasOnlyStringKeys reports whether the given map has only string keys,
+// in which case the keys can't collide.
+func hasOnlyStringKeys(m IMap) bool {
+	for k := range m {
+		if _, ok := k.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// stringifyKeys converts the keys of the given map, applying the collision policy.
+func stringifyKeys(m IMap, policy KeyCollisionPolicy) (Map, error) {
+	out := make(Map, len(m))
+	var origins map[string]interface{} // Original key of each entry, only needed for non-string keys.
+	if !hasOnlyStringKeys(m) {
+		origins = make(map[string]interface{}, len(m))
+	}
+	for k, v := range m {
+		s, ok := k.(string)
+		if !ok {
+			var err error
+			if s, err = toString(k, false); err != nil {
+				return nil, errors.Wrap(err, "toString map key")
+			}
+		}
+		if origins == nil {
+			out[s] = v
+			continue
+		}
+		prev, exists := origins[s]
+		if !exists {
+			origins[s] = k
+			out[s] = v
+			continue
+		}
+		first, last := prev, k
+		if keyLess(last, first) {
+			first, last = last, first
+		}
+		switch policy {
+		case CollisionFirstWins:
+			k = first
+		case CollisionLastWins:
+			k = last
+		default:
+			return nil, errors.Wrapf(ErrKeyCollision, "%#v (%T) and %#v (%T) both convert to %q", first, first, last, last, s)
+		}
+		origins[s] = k
+		out[s] = m[k]
+	}
+	return out, nil
+}
+
+// mapEntries stringifies the keys of the given typed map, applying the
+// collision policy when the key type is not a plain string.
+func mapEntries(v reflect.Value, policy KeyCollisionPolicy) ([]string, []reflect.Value, error) {
+	keys := make([]string, 0, v.Len())
+	values := make([]reflect.Value, 0, v.Len())
+	var origins map[string]int // Index of each entry, only needed for non-string keys.
+	var origKeys []interface{}
+	if kt := v.Type().Key(); kt.Kind() != reflect.String || kt.NumMethod() > 0 {
+		origins = make(map[string]int, v.Len())
+		origKeys = make([]interface{}, 0, v.Len())
+	}
+	for iter := v.MapRange(); iter.Next(); {
+		s, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "toString map key")
+		}
+		if origins == nil {
+			keys = append(keys, s)
+			values = append(values, iter.Value())
+			continue
+		}
+		k := iter.Key().Interface()
+		i, exists := origins[s]
+		if !exists {
+			origins[s] = len(keys)
+			keys = append(keys, s)
+			values = append(values, iter.Value())
+			origKeys = append(origKeys, k)
+			continue
+		}
+		first, last := origKeys[i], k
+		if keyLess(last, first) {
+			first, last = last, first
+		}
+		switch policy {
+		case CollisionFirstWins:
+			k = first
+		case CollisionLastWins:
+			k = last
+		default:
+			return nil, nil, errors.Wrapf(ErrKeyCollision, "%#v (%T) and %#v (%T) both convert to %q", first, first, last, last, s)
+		}
+		if k != origKeys[i] {
+			origKeys[i] = k
+			values[i] = iter.Value()
+		}
+	}
+	return keys, values, nil
+}
+
+// keyLess orders colliding keys by type name, then by value.
+func keyLess(a, b interface{}) bool {
+	ta, tb := fmt.Sprintf("%T", a), fmt.Sprintf("%T", b)
+	if ta != tb {
+		return ta < tb
+	}
+	return fmt.Sprintf("%#v", a) < fmt.Sprintf("%#v", b)
+}
@@ -0,0 +1,74 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIMapKeyCollision(t *testing.T) {
+	m := hcler.IMap{1: "int", "1": "string", int64(1): "int64", "2": "two"}
+
+	_, err := m.Map()
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrKeyCollision, errors.Cause(err))
+	assert.Contains(t, err.Error(), `both convert to "1"`)
+
+	_, err = hcler.IMap{true: "bool", "1": "string"}.Map()
+	require.Error(t, err)
+	assert.Equal(t, `true (bool) and "1" (string) both convert to "1": key collision`, err.Error())
+
+	got, err := m.MapWith(hcler.CollisionFirstWins)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"1": "int", "2": "two"}, got)
+
+	got, err = m.MapWith(hcler.CollisionLastWins)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"1": "string", "2": "two"}, got)
+
+	got, err = hcler.IMap{1: "a", "2": "b"}.Map()
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"1": "a", "2": "b"}, got)
+}
+
+func TestEncodeKeyCollision(t *testing.T) {
+	m := map[interface{}]interface{}{"a": hcler.IMap{1: "int", "1": "string"}}
+
+	err := assertEncodeCause(t, hcler.ErrKeyCollision, m)
+	assert.Contains(t, err.Error(), `1 (int) and "1" (string)`)
+
+	got, err := hcler.Encode(m, hcler.KeyCollisions(hcler.CollisionLastWins))
+	require.NoError(t, err)
+	assert.Equal(t, `{ a = { "1" = "string" } }`, got)
+
+	v, err := hcler.Normalize(m, hcler.KeyCollisions(hcler.CollisionFirstWins))
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"a": hcler.Map{"1": "int"}}, v)
+
+	_, err = hcler.IMap(m).DeepMap()
+	assert.Equal(t, hcler.ErrKeyCollision, errors.Cause(err))
+
+	_, err = hcler.EncodeBody(hcler.IMap{1: 1, "1": 2})
+	assert.Equal(t, hcler.ErrKeyCollision, errors.Cause(err))
+}
+
+func TestEncodeTypedMapKeyCollision(t *testing.T) {
+	m := map[interface{}]string{1: "int", "1": "string"}
+
+	err := assertEncodeCause(t, hcler.ErrKeyCollision, m)
+	assert.Contains(t, err.Error(), `1 (int) and "1" (string)`)
+
+	got, err := hcler.Encode(m, hcler.KeyCollisions(hcler.CollisionLastWins))
+	require.NoError(t, err)
+	assert.Equal(t, `{ "1" = "string" }`, got)
+
+	got, err = hcler.Encode(m, hcler.KeyCollisions(hcler.CollisionFirstWins))
+	require.NoError(t, err)
+	assert.Equal(t, `{ "1" = "int" }`, got)
+
+	_, err = hcler.ToCty(map[interface{}]int{1: 1, "1": 2})
+	assert.Equal(t, hcler.ErrKeyCollision, errors.Cause(err))
+}
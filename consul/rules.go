@@ -0,0 +1,163 @@
+// Package consul builds Consul ACL rules documents with hcler.
+package consul
+
+import (
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidPolicy is returned when a rule uses a policy its resource
+// doesn't support.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Policy is a Consul ACL rule disposition.
+type Policy string
+
+// Known policies. PolicyList is only valid for keys.
+const (
+	PolicyRead  Policy = "read"
+	PolicyWrite Policy = "write"
+	PolicyList  Policy = "list"
+	PolicyDeny  Policy = "deny"
+)
+
+// Valid reports whether p is a known policy.
+func (p Policy) Valid() bool {
+	switch p {
+	case PolicyRead, PolicyWrite, PolicyList, PolicyDeny:
+		return true
+	}
+	return false
+}
+
+// Rule is a resource rule, i.e. `key_prefix "app/" { policy = "read" }`.
+// Intentions is only valid for services.
+type Rule struct {
+	Name       string // Empty with Prefix matches all the resources.
+	Prefix     bool
+	Policy     Policy
+	Intentions Policy
+}
+
+// Rules is a Consul ACL rules document.
+type Rules struct {
+	ACL      Policy
+	Operator Policy
+	Keyring  Policy
+	Mesh     Policy
+	Peering  Policy
+
+	Agents   []Rule
+	Events   []Rule
+	Keys     []Rule
+	Nodes    []Rule
+	Queries  []Rule
+	Services []Rule
+	Sessions []Rule
+}
+
+// Body builds the rules document body.
+func (r Rules) Body() (hcler.OrderedMap, error) {
+	var body hcler.OrderedMap
+	for _, p := range []struct {
+		name   string
+		policy Policy
+	}{
+		{"acl", r.ACL},
+		{"operator", r.Operator},
+		{"keyring", r.Keyring},
+		{"mesh", r.Mesh},
+		{"peering", r.Peering},
+	} {
+		if p.policy == "" {
+			continue
+		}
+		if err := checkPolicy(p.policy, false); err != nil {
+			return nil, errors.Wrap(err, p.name)
+		}
+		body.Set(p.name, string(p.policy))
+	}
+	for _, res := range []struct {
+		name  string
+		rules []Rule
+	}{
+		{"agent", r.Agents},
+		{"event", r.Events},
+		{"key", r.Keys},
+		{"node", r.Nodes},
+		{"query", r.Queries},
+		{"service", r.Services},
+		{"session", r.Sessions},
+	} {
+		if err := setRules(&body, res.name, res.rules); err != nil {
+			return nil, err
+		}
+	}
+	if len(body) == 0 {
+		return nil, errors.New("at least one rule is required")
+	}
+	return body, nil
+}
+
+// Encode renders the rules document.
+func (r Rules) Encode() (string, error) {
+	body, err := r.Body()
+	if err != nil {
+		return "", err
+	}
+	return hcler.EncodeBody(body)
+}
+
+// setRules adds the exact match then the prefix blocks of the given resource.
+func setRules(body *hcler.OrderedMap, resource string, rules []Rule) error {
+	var exact, prefix hcler.Blocks
+	for _, rule := range rules {
+		name := resource
+		if rule.Prefix {
+			name += "_prefix"
+		}
+		b, err := rule.block(resource)
+		if err != nil {
+			return errors.Wrapf(err, "%s %q", name, rule.Name)
+		}
+		if rule.Prefix {
+			prefix = append(prefix, b)
+		} else {
+			exact = append(exact, b)
+		}
+	}
+	if len(exact) > 0 {
+		body.Set(resource, exact)
+	}
+	if len(prefix) > 0 {
+		body.Set(resource+"_prefix", prefix)
+	}
+	return nil
+}
+
+func (r Rule) block(resource string) (hcler.Block, error) {
+	if r.Name == "" && !r.Prefix {
+		return hcler.Block{}, errors.New("name is required for exact rules")
+	}
+	if err := checkPolicy(r.Policy, resource == "key"); err != nil {
+		return hcler.Block{}, err
+	}
+	body := hcler.OrderedMap{{Key: "policy", Value: string(r.Policy)}}
+	if r.Intentions != "" {
+		if resource != "service" {
+			return hcler.Block{}, errors.New("intentions are only valid for services")
+		}
+		if err := checkPolicy(r.Intentions, false); err != nil {
+			return hcler.Block{}, errors.Wrap(err, "intentions")
+		}
+		body.Set("intentions", string(r.Intentions))
+	}
+	return hcler.Block{Labels: []string{r.Name}, Body: body}, nil
+}
+
+func checkPolicy(p Policy, allowList bool) error {
+	if !p.Valid() || (p == PolicyList && !allowList) {
+		return errors.Wrapf(ErrInvalidPolicy, "%q", string(p))
+	}
+	return nil
+}
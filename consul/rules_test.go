@@ -0,0 +1,71 @@
+package consul_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler/consul"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	r := consul.Rules{
+		Operator: consul.PolicyRead,
+		Keys: []consul.Rule{
+			{Name: "app/", Prefix: true, Policy: consul.PolicyList},
+			{Name: "app/config", Policy: consul.PolicyWrite},
+		},
+		Services: []consul.Rule{{Prefix: true, Policy: consul.PolicyRead, Intentions: consul.PolicyRead}},
+	}
+	out, err := r.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, `operator = "read"
+key "app/config" {
+  policy = "write"
+}
+key_prefix "app/" {
+  policy = "list"
+}
+service_prefix "" {
+  policy     = "read"
+  intentions = "read"
+}
+`, out)
+}
+
+func TestRulesErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rules consul.Rules
+		err   string
+	}{
+		{name: "empty", rules: consul.Rules{}, err: "at least one rule is required"},
+		{name: "unknown policy", rules: consul.Rules{Keyring: "admin"}, err: `keyring: "admin": invalid policy`},
+		{
+			name:  "list on node",
+			rules: consul.Rules{Nodes: []consul.Rule{{Prefix: true, Policy: consul.PolicyList}}},
+			err:   `node_prefix "": "list": invalid policy`,
+		},
+		{
+			name:  "intentions on key",
+			rules: consul.Rules{Keys: []consul.Rule{{Name: "a", Policy: consul.PolicyRead, Intentions: consul.PolicyRead}}},
+			err:   `key "a": intentions are only valid for services`,
+		},
+		{
+			name:  "exact without name",
+			rules: consul.Rules{Agents: []consul.Rule{{Policy: consul.PolicyRead}}},
+			err:   `agent "": name is required for exact rules`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.rules.Encode()
+			require.Error(t, err)
+			assert.Equal(t, tc.err, err.Error())
+		})
+	}
+
+	_, err := consul.Rules{ACL: "root"}.Encode()
+	assert.Equal(t, consul.ErrInvalidPolicy, errors.Cause(err))
+}
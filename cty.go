@@ -0,0 +1,274 @@
+package hcler
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrUnknownValue is returned when writing an unknown cty.Value.
+var ErrUnknownValue = errors.New("unknown value")
+
+// FromCty converts the given cty.Value to Map, List and scalars:
+// objects and maps become Map, lists, sets and tuples become List,
+// strings and bools their Go counterpart, numbers int64, float64 or
+// *big.Float when they can't be represented exactly, and null nil.
+// Marks are ignored. Unknown values are kept as their cty.Value, which
+// ToCty converts back as is.
+func FromCty(v cty.Value) (interface{}, error) {
+	v, _ = v.UnmarkDeep()
+	e := newEncodeState(nil)
+	defer e.release()
+	return e.fromCty(v)
+}
+
+func (e *encodeState) fromCty(v cty.Value) (interface{}, error) {
+	if !v.IsKnown() {
+		return v, nil
+	}
+	if v.IsNull() {
+		return nil, nil
+	}
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString(), nil
+	case t == cty.Bool:
+		return v.True(), nil
+	case t == cty.Number:
+		bf := v.AsBigFloat()
+		if i, acc := bf.Int64(); acc == big.Exact {
+			return i, nil
+		}
+		if f, acc := bf.Float64(); acc == big.Exact {
+			return f, nil
+		}
+		return bf, nil
+	case t.IsObjectType() || t.IsMapType():
+		out := make(Map, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			e.pushKey(k.AsString())
+			val, err := e.fromCty(elem)
+			e.popPath()
+			if err != nil {
+				return nil, err
+			}
+			out[k.AsString()] = val
+		}
+		return out, nil
+	case t.IsListType() || t.IsSetType() || t.IsTupleType():
+		out := make(List, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			e.pushIndex(len(out))
+			val, err := e.fromCty(elem)
+			e.popPath()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, val)
+		}
+		return out, nil
+	default:
+		return nil, errors.Errorf("unsupported cty type %s at %s", t.FriendlyName(), e.pathString())
+	}
+}
+
+// ToCty converts the given value to a cty.Value: Map-like values become
+// objects, lists tuples, nil and Null null. Expressions and other Encoder
+// implementations can't be converted.
+func ToCty(v interface{}) (cty.Value, error) {
+	e := newEncodeState(nil)
+	defer e.release()
+	return e.toCty(v)
+}
+
+// nolint: gocyclo
+func (e *encodeState) toCty(v interface{}) (cty.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case cty.Value:
+		return v, nil
+	case Map:
+		return e.objectToCty(v)
+	case map[string]interface{}:
+		return e.objectToCty(v)
+	case IMap:
+		m, err := v.Map()
+		if err != nil {
+			return cty.NilVal, errors.Wrap(err, "convert to hcl.Map")
+		}
+		return e.objectToCty(m)
+	case map[interface{}]interface{}:
+		return e.toCty(IMap(v))
+	case OrderedMap:
+		if err := e.enterOrdered(v); err != nil {
+			return cty.NilVal, err
+		}
+		defer e.leave()
+		return e.objectToCty(v.Map())
+	case List:
+		return e.tupleToCty(v)
+	case []interface{}:
+		return e.tupleToCty(v)
+	case Block, Blocks:
+		return cty.NilVal, ErrBlockContext
+	case nullValue:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case Encoder:
+		if e.unknownEncoders {
+			// Expressions are only known once evaluated.
+			return cty.DynamicVal, nil
+		}
+		return cty.NilVal, errors.Errorf("%T can't be converted to a cty value", v)
+	case string:
+		return cty.StringVal(v), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	case int, int8, int16, int32, int64:
+		return cty.NumberIntVal(reflect.ValueOf(v).Int()), nil
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		return cty.NumberUIntVal(reflect.ValueOf(v).Uint()), nil
+	case float32:
+		return e.toCty(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return cty.NilVal, errors.Errorf("unsupported non-finite number %v at %s", v, e.pathString())
+		}
+		return cty.NumberFloatVal(v), nil
+	case *big.Int:
+		if v == nil {
+			return e.toCty(nil)
+		}
+		return cty.NumberVal(new(big.Float).SetInt(v)), nil
+	case *big.Float:
+		if v == nil {
+			return e.toCty(nil)
+		}
+		return cty.NumberVal(v), nil
+	case json.Number:
+		n, err := cty.ParseNumberVal(string(v))
+		return n, errors.Wrapf(err, "invalid json number %q", string(v))
+	case Rune, Runes, Byte, []byte, encoding.TextMarshaler, fmt.Stringer, error:
+		s, err := toString(v, false)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(s), nil
+	default:
+		return e.reflectToCty(reflect.ValueOf(v))
+	}
+}
+
+func (e *encodeState) objectToCty(m Map) (cty.Value, error) {
+	if len(m) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+	if err := e.enter(m, 0); err != nil {
+		return cty.NilVal, err
+	}
+	defer e.leave()
+
+	attrs := make(map[string]cty.Value, len(m))
+	for k, elem := range m {
+		e.pushKey(k)
+		val, err := e.toCty(elem)
+		e.popPath()
+		if err != nil {
+			return cty.NilVal, errors.Wrapf(err, "convert %q", k)
+		}
+		attrs[k] = val
+	}
+	return cty.ObjectVal(attrs), nil
+}
+
+func (e *encodeState) tupleToCty(l List) (cty.Value, error) {
+	if len(l) == 0 {
+		return cty.EmptyTupleVal, nil
+	}
+	if err := e.enterList(l); err != nil {
+		return cty.NilVal, err
+	}
+	defer e.leave()
+
+	elems := make([]cty.Value, 0, len(l))
+	for i, elem := range l {
+		e.pushIndex(i)
+		val, err := e.toCty(elem)
+		e.popPath()
+		if err != nil {
+			return cty.NilVal, errors.Wrap(err, "convert list element")
+		}
+		elems = append(elems, val)
+	}
+	return cty.TupleVal(elems), nil
+}
+
+// reflectToCty converts structs, typed collections and named types.
+func (e *encodeState) reflectToCty(v reflect.Value) (cty.Value, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.toCty(nil)
+		}
+		if v.Kind() == reflect.Ptr {
+			if err := e.enterPtr(v.Pointer(), 0, v.Type().Elem()); err != nil {
+				return cty.NilVal, err
+			}
+			defer e.leave()
+		}
+		return e.toCty(v.Elem().Interface())
+	case reflect.Struct:
+		m := Map{}
+		for _, f := range cachedTypeFields(v.Type()) {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			m[f.name] = fv.Interface()
+		}
+		return e.objectToCty(m)
+	case reflect.Map:
+		// The copy is a new map, check the original for cycles.
+		if err := e.enterPtr(v.Pointer(), 0, v.Type()); err != nil {
+			return cty.NilVal, err
+		}
+		defer e.leave()
+		keys, values, err := mapEntries(v, e.collisions)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		m := make(Map, len(keys))
+		for i, k := range keys {
+			m[k] = values[i].Interface()
+		}
+		return e.objectToCty(m)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return cty.StringVal(string(v.Bytes())), nil
+		}
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			if err := e.enterPtr(v.Pointer(), v.Len(), v.Type()); err != nil {
+				return cty.NilVal, err
+			}
+			defer e.leave()
+		}
+		l := make(List, v.Len())
+		for i := range l {
+			l[i] = v.Index(i).Interface()
+		}
+		return e.tupleToCty(l)
+	}
+	if s := scalarValue(v); s != nil && v.Type() != reflect.TypeOf(s) {
+		return e.toCty(s)
+	}
+	return cty.NilVal, &UnsupportedTypeError{Type: v.Type()}
+}
@@ -0,0 +1,196 @@
+package hcler_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFromCty(t *testing.T) {
+	big1e30, _ := new(big.Float).SetString("1e30")
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name":     cty.StringVal("web"),
+		"count":    cty.NumberIntVal(3),
+		"ratio":    cty.NumberFloatVal(0.5),
+		"huge":     cty.NumberVal(big1e30),
+		"enabled":  cty.True,
+		"nothing":  cty.NullVal(cty.String),
+		"tags":     cty.MapVal(map[string]cty.Value{"team": cty.StringVal("core")}),
+		"ports":    cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+		"zones":    cty.SetVal([]cty.Value{cty.StringVal("a")}),
+		"mixed":    cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+		"secret":   cty.StringVal("s3cr3t").Mark("sensitive"),
+		"empty":    cty.EmptyObjectVal,
+		"emptyTup": cty.EmptyTupleVal,
+	})
+	got, err := hcler.FromCty(v)
+	require.NoError(t, err)
+	require.IsType(t, (*big.Float)(nil), got.(hcler.Map)["huge"])
+	assert.Equal(t, "1e+30", got.(hcler.Map)["huge"].(*big.Float).Text('g', 10))
+	delete(got.(hcler.Map), "huge")
+	assert.Equal(t, hcler.Map{
+		"name":     "web",
+		"count":    int64(3),
+		"ratio":    0.5,
+		"enabled":  true,
+		"nothing":  nil,
+		"tags":     hcler.Map{"team": "core"},
+		"ports":    hcler.List{int64(80), int64(443)},
+		"zones":    hcler.List{"a"},
+		"mixed":    hcler.List{"a", int64(1)},
+		"secret":   "s3cr3t",
+		"empty":    hcler.Map{},
+		"emptyTup": hcler.List{},
+	}, got)
+
+	precise, _ := new(big.Float).SetPrec(512).SetString("0.1000000000000000000000000001")
+	got, err = hcler.FromCty(cty.NumberVal(precise))
+	require.NoError(t, err)
+	assert.IsType(t, (*big.Float)(nil), got)
+}
+
+func TestFromCtyUnknown(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"list": cty.TupleVal([]cty.Value{cty.True, cty.UnknownVal(cty.String)}),
+	})
+	got, err := hcler.FromCty(v)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"list": hcler.List{true, cty.UnknownVal(cty.String)}}, got)
+
+	back, err := hcler.ToCty(got)
+	require.NoError(t, err)
+	assert.True(t, back.RawEquals(v))
+}
+
+func TestFromCtyError(t *testing.T) {
+	_, err := hcler.FromCty(cty.CapsuleVal(cty.Capsule("test", reflect.TypeOf(struct{}{})), &struct{}{}))
+	require.Error(t, err)
+}
+
+type ctyStruct struct {
+	Name  string            `hcl:"name"`
+	Port  port              `hcl:"port"`
+	Tags  map[string]string `hcl:"tags,omitempty"`
+	Extra *ctyStruct        `hcl:"extra,omitempty"`
+}
+
+func TestToCty(t *testing.T) {
+	got, err := hcler.ToCty(hcler.Map{
+		"name":   "web",
+		"count":  3,
+		"uint":   uint8(3),
+		"ratio":  float32(0.5),
+		"big":    big.NewInt(42),
+		"json":   json.Number("1.5"),
+		"on":     true,
+		"null":   nil,
+		"keys":   hcler.IMap{1: "one"},
+		"ports":  []interface{}{80, "http"},
+		"empty":  hcler.List{},
+		"nested": map[string]interface{}{},
+		"struct": ctyStruct{Name: "db", Port: 5432, Extra: &ctyStruct{Name: "replica"}},
+		"typed":  []string{"a", "b"},
+		"cty":    cty.StringVal("raw"),
+	})
+	require.NoError(t, err)
+
+	expect := cty.ObjectVal(map[string]cty.Value{
+		"name":   cty.StringVal("web"),
+		"count":  cty.NumberIntVal(3),
+		"uint":   cty.NumberUIntVal(3),
+		"ratio":  cty.NumberFloatVal(0.5),
+		"big":    cty.NumberIntVal(42),
+		"json":   cty.NumberFloatVal(1.5),
+		"on":     cty.True,
+		"null":   cty.NullVal(cty.DynamicPseudoType),
+		"keys":   cty.ObjectVal(map[string]cty.Value{"1": cty.StringVal("one")}),
+		"ports":  cty.TupleVal([]cty.Value{cty.NumberIntVal(80), cty.StringVal("http")}),
+		"empty":  cty.EmptyTupleVal,
+		"nested": cty.EmptyObjectVal,
+		"struct": cty.ObjectVal(map[string]cty.Value{
+			"name":  cty.StringVal("db"),
+			"port":  cty.NumberIntVal(5432),
+			"extra": cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("replica"), "port": cty.NumberIntVal(0)}),
+		}),
+		"typed": cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		"cty":   cty.StringVal("raw"),
+	})
+	assert.True(t, expect.RawEquals(got), "%#v", got)
+
+	// Round trip.
+	back, err := hcler.FromCty(got)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.List{int64(80), "http"}, back.(hcler.Map)["ports"])
+}
+
+func TestToCtyError(t *testing.T) {
+	cyclic := hcler.Map{}
+	cyclic["self"] = cyclic
+
+	for name, v := range map[string]interface{}{
+		"expression": hcler.Map{"a": expr.Ref("var", "a")},
+		"template":   hcler.List{hcler.NewTemplate("x")},
+		"block":      hcler.Map{"b": hcler.Block{}},
+		"complex":    complex(1, 1),
+		"chan":       make(chan int),
+		"json":       json.Number("x"),
+		"cycle":      cyclic,
+		"nan":        math.NaN(),
+		"inf":        float32(math.Inf(-1)),
+	} {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			_, err := hcler.ToCty(v)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestToCtyPointerCycle(t *testing.T) {
+	type node struct {
+		Name string `hcl:"name"`
+		Next *node  `hcl:"next"`
+	}
+	n := &node{Name: "a"}
+	n.Next = n
+
+	_, err := hcler.ToCty(n)
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+
+	// Shared pointers are not cycles.
+	leaf := &node{Name: "leaf"}
+	_, err = hcler.ToCty(hcler.List{leaf, leaf})
+	require.NoError(t, err)
+}
+
+func TestToCtyTypedCycle(t *testing.T) {
+	type T map[string]interface{}
+	type S []interface{}
+
+	m := T{}
+	m["self"] = m
+	_, err := hcler.ToCty(m)
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+
+	s := S{nil}
+	s[0] = s
+	_, err = hcler.ToCty(s)
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+
+	// Shared values are not cycles.
+	leaf := T{"a": 1}
+	_, err = hcler.ToCty(S{leaf, leaf})
+	require.NoError(t, err)
+}
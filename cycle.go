@@ -0,0 +1,120 @@
+package hcler
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+// Common errors.
+var (
+	ErrCycle    = errors.New("encoding cycle")
+	ErrMaxDepth = errors.New("max depth exceeded")
+)
+
+// visit identifies a map, a slice or a pointer being encoded.
+// Slices sharing the same backing array are distinguished by their length,
+// pointers to a struct and to its first field by their type.
+type visit struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+var (
+	listType       = reflect.TypeOf(List(nil))
+	orderedMapType = reflect.TypeOf(OrderedMap(nil))
+)
+
+// pathElem is either a map key or a list index.
+type pathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// enter marks the given map or slice as being encoded.
+// Fails if it is already being encoded or if the max depth is reached.
+func (e *encodeState) enter(v interface{}, n int) error {
+	rv := reflect.ValueOf(v)
+	return e.enterPtr(rv.Pointer(), n, rv.Type())
+}
+
+// enterList is enter for lists, avoiding the allocation of
+// boxing the slice in an interface.
+func (e *encodeState) enterList(l List) error {
+	return e.enterPtr(uintptr(unsafe.Pointer(&l[0])), len(l), listType)
+}
+
+// enterOrdered is enter for ordered maps, which are slices as well.
+func (e *encodeState) enterOrdered(m OrderedMap) error {
+	var ptr uintptr
+	if len(m) > 0 {
+		ptr = uintptr(unsafe.Pointer(&m[0]))
+	}
+	return e.enterPtr(ptr, len(m), orderedMapType)
+}
+
+// enterObject is enter for any map-like value.
+func (e *encodeState) enterObject(v interface{}) error {
+	if m, ok := v.(OrderedMap); ok {
+		return e.enterOrdered(m)
+	}
+	return e.enter(v, 0)
+}
+
+func (e *encodeState) enterPtr(ptr uintptr, n int, typ reflect.Type) error {
+	if e.maxDepth > 0 && len(e.stack) >= e.maxDepth {
+		return errors.Wrap(ErrMaxDepth, e.pathString())
+	}
+	k := visit{ptr: ptr, len: n, typ: typ}
+	for _, elem := range e.stack {
+		if elem == k {
+			return errors.Wrap(ErrCycle, e.pathString())
+		}
+	}
+	e.stack = append(e.stack, k)
+	return nil
+}
+
+// leave pops the last entered map or slice.
+func (e *encodeState) leave() {
+	e.stack = e.stack[:len(e.stack)-1]
+}
+
+func (e *encodeState) pushKey(k string) {
+	e.path = append(e.path, pathElem{key: k})
+}
+
+func (e *encodeState) pushIndex(i int) {
+	e.path = append(e.path, pathElem{index: i, isIndex: true})
+}
+
+func (e *encodeState) popPath() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+// pathString renders the current path, i.e. `foo.bar[0]["baz qux"]`.
+func (e *encodeState) pathString() string {
+	if len(e.path) == 0 {
+		return "<root>"
+	}
+	var b strings.Builder
+	for i, elem := range e.path {
+		switch {
+		case elem.isIndex:
+			_, _ = b.WriteString("[" + strconv.Itoa(elem.index) + "]")
+		case IsIdentifier(elem.key):
+			if i > 0 {
+				_, _ = b.WriteString(".")
+			}
+			_, _ = b.WriteString(elem.key)
+		default:
+			_, _ = b.WriteString("[" + strconv.Quote(elem.key) + "]")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertEncodeCause(t *testing.T, cause error, v interface{}, opts ...hcler.Option) error {
+	t.Helper()
+
+	_, err := hcler.Encode(v, opts...)
+	require.Error(t, err)
+	assert.Equal(t, cause, errors.Cause(err))
+	return err
+}
+
+func TestEncodeCycle(t *testing.T) {
+	t.Run("map", func(t *testing.T) {
+		m := hcler.Map{}
+		m["self"] = m
+		err := assertEncodeCause(t, hcler.ErrCycle, m)
+		assert.Contains(t, err.Error(), "self: encoding cycle")
+	})
+	t.Run("std_map", func(t *testing.T) {
+		m := map[string]interface{}{}
+		m["foo"] = map[string]interface{}{"bar": m}
+		err := assertEncodeCause(t, hcler.ErrCycle, m)
+		assert.Contains(t, err.Error(), "foo.bar: encoding cycle")
+	})
+	t.Run("imap", func(t *testing.T) {
+		m := hcler.IMap{}
+		m[1] = hcler.IMap{"a b": m}
+		err := assertEncodeCause(t, hcler.ErrCycle, m)
+		assert.Contains(t, err.Error(), `["1"]["a b"]: encoding cycle`)
+	})
+	t.Run("through_list", func(t *testing.T) {
+		l := hcler.List{"foo", nil}
+		m := hcler.Map{"list": l}
+		l[1] = m
+		err := assertEncodeCause(t, hcler.ErrCycle, m)
+		assert.Contains(t, err.Error(), "list[1]: encoding cycle")
+	})
+	t.Run("list", func(t *testing.T) {
+		l := []interface{}{nil}
+		l[0] = l
+		err := assertEncodeCause(t, hcler.ErrCycle, l)
+		assert.Contains(t, err.Error(), "[0]: encoding cycle")
+	})
+	t.Run("shared_is_not_a_cycle", func(t *testing.T) {
+		shared := hcler.Map{"a": 1}
+		sharedList := hcler.List{1, 2}
+		v := hcler.List{shared, shared, sharedList, sharedList[:1], hcler.Map{"shared": shared}}
+		got, err := hcler.Encode(v)
+		require.NoError(t, err)
+		assert.Equal(t, `[ { a = 1 }, { a = 1 }, [ 1, 2 ], [ 1 ], { shared = { a = 1 } } ]`, got)
+	})
+}
+
+func TestEncodeFirstFieldPointer(t *testing.T) {
+	type inner struct {
+		X int `hcl:"x"`
+	}
+	type outer struct {
+		In  inner  `hcl:"in"`
+		Ref *inner `hcl:"ref"`
+	}
+	o := &outer{In: inner{X: 1}}
+	o.Ref = &o.In
+
+	got, err := hcler.Encode(o)
+	require.NoError(t, err)
+	assert.Equal(t, `{ in = { x = 1 }, ref = { x = 1 } }`, got)
+
+	_, err = hcler.ToCty(o)
+	require.NoError(t, err)
+}
+
+func TestEncodeMaxDepth(t *testing.T) {
+	v := hcler.Map{"a": hcler.List{hcler.Map{"b": 1}}}
+
+	got, err := hcler.Encode(v, hcler.MaxDepth(3))
+	require.NoError(t, err)
+	assert.Equal(t, `{ a = [ { b = 1 } ] }`, got)
+
+	err = assertEncodeCause(t, hcler.ErrMaxDepth, v, hcler.MaxDepth(2))
+	assert.Contains(t, err.Error(), "a[0]: max depth exceeded")
+}
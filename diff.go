@@ -0,0 +1,339 @@
+package hcler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// Change kinds.
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Changed
+)
+
+// String renders the kind as in a plan: +, - or ~.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	case Changed:
+		return "~"
+	default:
+		return "?"
+	}
+}
+
+// Change is a difference between two trees.
+type Change struct {
+	Kind ChangeKind
+	Path string      // i.e. `module.vpc.cidr`, `zones[0]`.
+	Old  interface{} // Unset for Added.
+	New  interface{} // Unset for Removed.
+}
+
+// String renders the change, i.e. `~ module.vpc.cidr: "10.1.0.0/16" => "10.0.0.0/16"`.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return c.Kind.String() + " " + c.Path + ": " + renderValue(c.New)
+	case Removed:
+		return c.Kind.String() + " " + c.Path + ": " + renderValue(c.Old)
+	default:
+		return c.Kind.String() + " " + c.Path + ": " + renderValue(c.Old) + " => " + renderValue(c.New)
+	}
+}
+
+// Changes are the differences between two trees, in path order.
+type Changes []Change
+
+// String renders one change per line.
+func (c Changes) String() string {
+	var b strings.Builder
+	for _, change := range c {
+		_, _ = b.WriteString(change.String())
+		_ = b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Diff reports the attributes and blocks added, removed or changed from a to b.
+//
+// Map-like values are compared key by key, ignoring order, lists
+// index by index and blocks by type and labels, then by position for
+// unlabeled or repeated blocks. Other values are compared as with Equal.
+func Diff(a, b interface{}) (Changes, error) {
+	e := newCanonicalState()
+	defer e.release()
+
+	var out Changes
+	if err := e.diff(&out, a, b); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (e *encodeState) diff(out *Changes, a, b interface{}) error {
+	aObj, aIsObj, err := objectEntries(a)
+	if err != nil {
+		return err
+	}
+	bObj, bIsObj, err := objectEntries(b)
+	if err != nil {
+		return err
+	}
+	if aIsObj && bIsObj {
+		if err := e.enterObject(b); err != nil {
+			return err
+		}
+		defer e.leave()
+		return e.diffObjects(out, aObj, bObj)
+	}
+	if aList, ok := listValue(a); ok {
+		if bList, ok := listValue(b); ok {
+			return e.diffLists(out, aList, bList)
+		}
+	}
+	if aBlocks, ok := blockList(a); ok {
+		if bBlocks, ok := blockList(b); ok {
+			return e.diffBlocks(out, aBlocks, bBlocks)
+		}
+	}
+	if isBlock(a) || isBlock(b) {
+		e.addChange(out, Changed, a, b)
+		return nil
+	}
+	ca, err := e.canonicalString(a)
+	if err != nil {
+		return err
+	}
+	cb, err := e.canonicalString(b)
+	if err != nil {
+		return err
+	}
+	if ca != cb {
+		e.addChange(out, Changed, a, b)
+	}
+	return nil
+}
+
+func (e *encodeState) diffObjects(out *Changes, a, b OrderedMap) error {
+	keys := make([]string, 0, len(a)+len(b))
+	for _, kv := range a {
+		keys = append(keys, kv.Key)
+	}
+	for _, kv := range b {
+		if _, ok := a.Get(kv.Key); !ok {
+			keys = append(keys, kv.Key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a.Get(k)
+		bv, inB := b.Get(k)
+		e.pushKey(k)
+		var err error
+		switch {
+		case !inA:
+			e.addBlockChanges(out, Added, bv)
+		case !inB:
+			e.addBlockChanges(out, Removed, av)
+		default:
+			err = e.diff(out, av, bv)
+		}
+		e.popPath()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encodeState) diffLists(out *Changes, a, b List) error {
+	if len(a) > 0 {
+		if err := e.enterList(a); err != nil {
+			return err
+		}
+		defer e.leave()
+	}
+	// The same list on both sides is entered once.
+	if len(b) > 0 && (len(a) != len(b) || &a[0] != &b[0]) {
+		if err := e.enterList(b); err != nil {
+			return err
+		}
+		defer e.leave()
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		e.pushIndex(i)
+		var err error
+		switch {
+		case i >= len(a):
+			e.addChange(out, Added, nil, b[i])
+		case i >= len(b):
+			e.addChange(out, Removed, a[i], nil)
+		default:
+			err = e.diff(out, a[i], b[i])
+		}
+		e.popPath()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffBlocks pairs blocks by labels. Unlabeled blocks and blocks
+// sharing the same labels are paired by position, indexed like lists.
+func (e *encodeState) diffBlocks(out *Changes, a, b Blocks) error {
+	aGroups, bGroups := groupBlocks(a), groupBlocks(b)
+	for _, bg := range bGroups {
+		if findGroup(aGroups, bg.labels) == nil {
+			aGroups = append(aGroups, blockGroup{labels: bg.labels})
+		}
+	}
+	for _, ag := range aGroups {
+		var bBlocks Blocks
+		if bg := findGroup(bGroups, ag.labels); bg != nil {
+			bBlocks = bg.blocks
+		}
+		indexed := len(ag.labels) == 0 || len(ag.blocks) > 1 || len(bBlocks) > 1
+		e.pushLabels(ag.labels)
+		for i := 0; i < len(ag.blocks) || i < len(bBlocks); i++ {
+			if indexed {
+				e.pushIndex(i)
+			}
+			var err error
+			switch {
+			case i >= len(ag.blocks):
+				e.addChange(out, Added, nil, bodyValue(bBlocks[i].Body))
+			case i >= len(bBlocks):
+				e.addChange(out, Removed, bodyValue(ag.blocks[i].Body), nil)
+			default:
+				err = e.diff(out, bodyValue(ag.blocks[i].Body), bodyValue(bBlocks[i].Body))
+			}
+			if indexed {
+				e.popPath()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		e.popLabels(ag.labels)
+	}
+	return nil
+}
+
+// addBlockChanges adds a change for the given value, one per block
+// for Block and Blocks.
+func (e *encodeState) addBlockChanges(out *Changes, kind ChangeKind, v interface{}) {
+	blocks, ok := blockList(v)
+	if !ok {
+		if kind == Added {
+			e.addChange(out, kind, nil, v)
+		} else {
+			e.addChange(out, kind, v, nil)
+		}
+		return
+	}
+	for _, g := range groupBlocks(blocks) {
+		indexed := len(g.labels) == 0 || len(g.blocks) > 1
+		e.pushLabels(g.labels)
+		for i, b := range g.blocks {
+			if indexed {
+				e.pushIndex(i)
+			}
+			if kind == Added {
+				e.addChange(out, kind, nil, bodyValue(b.Body))
+			} else {
+				e.addChange(out, kind, bodyValue(b.Body), nil)
+			}
+			if indexed {
+				e.popPath()
+			}
+		}
+		e.popLabels(g.labels)
+	}
+}
+
+func (e *encodeState) addChange(out *Changes, kind ChangeKind, old, new interface{}) {
+	*out = append(*out, Change{Kind: kind, Path: e.pathString(), Old: old, New: new})
+}
+
+func (e *encodeState) pushLabels(labels []string) {
+	for _, l := range labels {
+		e.pushKey(l)
+	}
+}
+
+func (e *encodeState) popLabels(labels []string) {
+	e.path = e.path[:len(e.path)-len(labels)]
+}
+
+func blockList(v interface{}) (Blocks, bool) {
+	switch v := v.(type) {
+	case Block:
+		return Blocks{v}, true
+	case Blocks:
+		return v, true
+	}
+	return nil, false
+}
+
+// blockGroup lists the blocks sharing the same labels.
+type blockGroup struct {
+	labels []string
+	blocks Blocks
+}
+
+// groupBlocks groups the given blocks by labels, in order of appearance.
+func groupBlocks(blocks Blocks) []blockGroup {
+	var groups []blockGroup
+	for _, b := range blocks {
+		if g := findGroup(groups, b.Labels); g != nil {
+			g.blocks = append(g.blocks, b)
+			continue
+		}
+		groups = append(groups, blockGroup{labels: b.Labels, blocks: Blocks{b}})
+	}
+	return groups
+}
+
+func findGroup(groups []blockGroup, labels []string) *blockGroup {
+	for i := range groups {
+		if equalLabels(groups[i].labels, labels) {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// bodyValue returns the given block body, an empty Map for nil.
+func bodyValue(body interface{}) interface{} {
+	if body == nil {
+		return Map{}
+	}
+	return body
+}
+
+// renderValue encodes the given value for display.
+func renderValue(v interface{}) string {
+	if b, ok := v.(Block); ok {
+		var labels string
+		for _, l := range b.Labels {
+			labels += fmt.Sprintf("%q ", l)
+		}
+		return labels + renderValue(bodyValue(b.Body))
+	}
+	s, err := Encode(v, SortKeys())
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package hcler_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	a := hcler.Map{
+		"region": "us-east-1",
+		"count":  1,
+		"tags":   hcler.OrderedMap{{Key: "env", Value: "dev"}, {Key: "team", Value: "infra"}},
+		"zones":  hcler.List{"a", "b"},
+		"module": hcler.Blocks{
+			{Labels: []string{"vpc"}, Body: hcler.Map{"cidr": "10.1.0.0/16"}},
+			{Labels: []string{"legacy"}},
+		},
+		"old": true,
+	}
+	b := map[interface{}]interface{}{
+		"region": "us-east-1",
+		"count":  json.Number("1"),
+		"tags":   hcler.Map{"team": "infra", "env": "prod"},
+		"zones":  []interface{}{"a", "b", "c"},
+		"module": hcler.Blocks{
+			{Labels: []string{"vpc"}, Body: hcler.Map{"cidr": "10.0.0.0/16"}},
+			{Labels: []string{"db"}, Body: hcler.Map{"source": "./db"}},
+		},
+		"resource": hcler.Block{Labels: []string{"aws_instance", "web"}, Body: hcler.Map{"ami": "abc"}},
+	}
+
+	changes, err := hcler.Diff(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Changes{
+		{Kind: hcler.Changed, Path: "module.vpc.cidr", Old: "10.1.0.0/16", New: "10.0.0.0/16"},
+		{Kind: hcler.Removed, Path: "module.legacy", Old: hcler.Map{}},
+		{Kind: hcler.Added, Path: "module.db", New: hcler.Map{"source": "./db"}},
+		{Kind: hcler.Removed, Path: "old", Old: true},
+		{Kind: hcler.Added, Path: "resource.aws_instance.web", New: hcler.Map{"ami": "abc"}},
+		{Kind: hcler.Changed, Path: "tags.env", Old: "dev", New: "prod"},
+		{Kind: hcler.Added, Path: "zones[2]", New: "c"},
+	}, changes)
+
+	assert.Equal(t, `~ module.vpc.cidr: "10.1.0.0/16" => "10.0.0.0/16"
+- module.legacy: {}
++ module.db: { source = "./db" }
+- old: "1"
++ resource.aws_instance.web: { ami = "abc" }
+~ tags.env: "dev" => "prod"
++ zones[2]: "c"
+`, changes.String())
+
+	changes, err = hcler.Diff(b, b)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiffKinds(t *testing.T) {
+	changes, err := hcler.Diff(hcler.Map{"a": hcler.List{1}, "b": hcler.Block{}}, hcler.Map{"a": hcler.Map{"x": 1}, "b": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "~ a: [ 1 ] => { x = 1 }\n~ b: {} => 1\n", changes.String())
+
+	changes, err = hcler.Diff(hcler.List{1, 2}, hcler.List{1})
+	require.NoError(t, err)
+	assert.Equal(t, "- [1]: 2\n", changes.String())
+
+	changes, err = hcler.Diff(hcler.Map{"x": 0.121}, hcler.Map{"x": 0.124})
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	changes, err = hcler.Diff("a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, `~ <root>: "a" => "b"`+"\n", changes.String())
+}
+
+func TestDiffRepeatedBlocks(t *testing.T) {
+	one := hcler.Map{"a": 1}
+	two := hcler.Map{"a": 2}
+
+	changes, err := hcler.Diff(hcler.Map{"c": hcler.Blocks{{Body: one}}}, hcler.Map{"c": hcler.Blocks{{Body: one}, {Body: two}}})
+	require.NoError(t, err)
+	assert.Equal(t, "+ c[1]: { a = 2 }\n", changes.String())
+
+	changes, err = hcler.Diff(hcler.Map{"c": hcler.Blocks{{Body: one}, {Body: two}}}, hcler.Map{"c": hcler.Blocks{{Body: one}, {Body: one}}})
+	require.NoError(t, err)
+	assert.Equal(t, "~ c[1].a: 2 => 1\n", changes.String())
+
+	// Repeated labels are paired by position as well.
+	a := hcler.Map{"port": hcler.Blocks{{Labels: []string{"http"}, Body: one}, {Labels: []string{"http"}, Body: two}}}
+	b := hcler.Map{"port": hcler.Blocks{{Labels: []string{"http"}, Body: one}}}
+	changes, err = hcler.Diff(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, "- port.http[1]: { a = 2 }\n", changes.String())
+
+	changes, err = hcler.Diff(hcler.Map{}, hcler.Map{"c": hcler.Blocks{{Body: one}, {Body: two}}})
+	require.NoError(t, err)
+	assert.Equal(t, "+ c[0]: { a = 1 }\n+ c[1]: { a = 2 }\n", changes.String())
+}
+
+func TestDiffError(t *testing.T) {
+	_, err := hcler.Diff(hcler.Map{"a": func() {}}, hcler.Map{"a": 1})
+	assert.Error(t, err)
+
+	cyclic := hcler.Map{}
+	cyclic["self"] = cyclic
+	_, err = hcler.Diff(cyclic, cyclic)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+
+	cyclicList := hcler.List{nil}
+	cyclicList[0] = cyclicList
+	_, err = hcler.Diff(cyclicList, cyclicList)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+	_, err = hcler.Diff(hcler.Map{"a": cyclicList}, hcler.Map{"a": hcler.List{cyclicList}})
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+
+	// Shared lists are not cycles.
+	shared := hcler.List{1}
+	_, err = hcler.Diff(hcler.List{shared, shared}, hcler.List{shared, shared})
+	assert.NoError(t, err)
+}
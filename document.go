@@ -0,0 +1,244 @@
+package hcler
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+)
+
+// ErrPathNotFound is returned when a Document path doesn't resolve.
+var ErrPathNotFound = errors.New("path not found")
+
+// Document is an existing HCL file edited in place. Untouched bytes,
+// including comments and layout, are preserved.
+//
+// Paths are dot separated: block types followed by their labels, then
+// the attribute name, i.e. "module.vpc.cidr" for the cidr attribute of
+// the `module "vpc"` block. Labels containing dots can't be addressed.
+type Document struct {
+	file *hclwrite.File
+	opts []Option
+}
+
+// ParseDocument parses the given HCL file for editing.
+// The options apply to the values set in the document.
+func ParseDocument(src []byte, filename string, opts ...Option) (*Document, error) {
+	f, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(diags, "parse %s", filename)
+	}
+	return &Document{file: f, opts: opts}, nil
+}
+
+// File returns the underlying hclwrite.File.
+func (d *Document) File() *hclwrite.File {
+	return d.file
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	// File.Bytes formats the whole file, build the tokens as they are.
+	return d.file.BuildTokens(nil).Bytes()
+}
+
+// Set sets the attribute at the given path. The blocks leading to it
+// must exist.
+//
+// Block and Blocks values set blocks instead: the last path element is
+// the block type and the labels come from the value. Existing blocks
+// with the same type and labels are replaced in place, others appended.
+func (d *Document) Set(path string, v interface{}) error {
+	parts := strings.Split(path, ".")
+	parent, depth := resolveBodyDepth(d.file.Body(), parts[:len(parts)-1], 0)
+	if parent == nil {
+		return errors.Wrap(ErrPathNotFound, path)
+	}
+	name := parts[len(parts)-1]
+	indent := depth * 2
+
+	e := newEncodeState(d.opts)
+	defer e.release()
+	e.path = append(e.path, pathElem{key: path})
+
+	switch b := v.(type) {
+	case Block:
+		return d.setBlocks(e, parent, name, Blocks{b}, indent)
+	case Blocks:
+		return d.setBlocks(e, parent, name, b, indent)
+	}
+	if !IsIdentifier(name) {
+		return errors.Wrapf(ErrInvalidKey, "attribute %q", name)
+	}
+	start := len(e.buf)
+	if err := e.encode(v); err != nil {
+		return errors.Wrapf(err, "encode %q", path)
+	}
+	// Values are encoded on a single line, as they can't be indented
+	// to their position.
+	toks, err := lexTokens(hclwrite.Format(e.buf[start:]))
+	if err != nil {
+		return err
+	}
+	if len(toks) == 0 {
+		return errors.Errorf("encode %q: empty value", path)
+	}
+	// The inserted tokens are not formatted, space them as hclwrite.Format would.
+	toks[0].SpacesBefore = 1
+	if attr := parent.GetAttribute(name); attr != nil {
+		if old := attr.Expr().BuildTokens(nil); len(old) > 0 {
+			toks[0].SpacesBefore = old[0].SpacesBefore
+		}
+		parent.SetAttributeRaw(name, toks)
+		return nil
+	}
+	attr := parent.SetAttributeRaw(name, toks)
+	attrToks := attr.BuildTokens(nil)
+	attrToks[0].SpacesBefore = indent // Name.
+	attrToks[1].SpacesBefore = 1      // Equal sign.
+	return nil
+}
+
+func (d *Document) setBlocks(e *encodeState, parent *hclwrite.Body, typ string, blocks Blocks, indent int) error {
+	for _, b := range blocks {
+		// Build the block in a scratch file to format it.
+		scratch := hclwrite.NewEmptyFile()
+		if err := e.writeBody(scratch.Body(), Map{typ: b}); err != nil {
+			return err
+		}
+		f, diags := hclwrite.ParseConfig(hclwrite.Format(scratch.Bytes()), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return errors.Wrapf(diags, "format block %q", typ)
+		}
+		block := f.Body().Blocks()[0]
+
+		// The scratch block is formatted at the top level, indent it to its position.
+		indentTokens(block.BuildTokens(nil), indent)
+
+		if existing := findBlock(parent, typ, b.Labels); existing != nil {
+			existing.Body().Clear()
+			existing.Body().AppendUnstructuredTokens(block.Body().BuildTokens(nil))
+		} else {
+			if !endsWithBlankLine(parent) {
+				parent.AppendNewline()
+			}
+			parent.AppendBlock(block)
+		}
+		// Parse the document back so the new content is structured.
+		if err := d.reparse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the attribute or the block at the given path.
+func (d *Document) Delete(path string) error {
+	parts := strings.Split(path, ".")
+	if parent := resolveBody(d.file.Body(), parts[:len(parts)-1]); parent != nil {
+		if parent.GetAttribute(parts[len(parts)-1]) != nil {
+			parent.RemoveAttribute(parts[len(parts)-1])
+			return nil
+		}
+	}
+	for i := range parts {
+		parent := resolveBody(d.file.Body(), parts[:i])
+		if parent == nil {
+			continue
+		}
+		if b := findBlock(parent, parts[i], parts[i+1:]); b != nil {
+			parent.RemoveBlock(b)
+			return nil
+		}
+	}
+	return errors.Wrap(ErrPathNotFound, path)
+}
+
+// endsWithBlankLine reports whether the given body is empty or ends with
+// a blank line, in which case no separator is needed before a new block.
+func endsWithBlankLine(body *hclwrite.Body) bool {
+	toks := body.BuildTokens(nil)
+	if len(toks) == 0 {
+		return true
+	}
+	if len(toks) < 2 {
+		return false
+	}
+	return toks[len(toks)-1].Type == hclsyntax.TokenNewline && toks[len(toks)-2].Type == hclsyntax.TokenNewline
+}
+
+// indentTokens adds n spaces at the beginning of each line of the given
+// tokens, leaving heredoc content as is.
+func indentTokens(toks hclwrite.Tokens, n int) {
+	lineStart, heredoc := true, false
+	for _, tok := range toks {
+		switch {
+		case tok.Type == hclsyntax.TokenCHeredoc:
+			heredoc = false
+		case heredoc:
+		case lineStart && tok.Type != hclsyntax.TokenNewline:
+			tok.SpacesBefore += n
+		}
+		if tok.Type == hclsyntax.TokenOHeredoc {
+			heredoc = true
+		}
+		lineStart = tok.Type == hclsyntax.TokenNewline
+	}
+}
+
+func (d *Document) reparse() error {
+	f, diags := hclwrite.ParseConfig(d.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return errors.Wrap(diags, "parse edited document")
+	}
+	d.file = f
+	return nil
+}
+
+// resolveBody returns the body of the block addressed by the given path
+// elements, the root body if empty, nil if not found.
+func resolveBody(body *hclwrite.Body, parts []string) *hclwrite.Body {
+	found, _ := resolveBodyDepth(body, parts, 0)
+	return found
+}
+
+// resolveBodyDepth is resolveBody also returning the nesting depth of the body.
+func resolveBodyDepth(body *hclwrite.Body, parts []string, depth int) (*hclwrite.Body, int) {
+	if len(parts) == 0 {
+		return body, depth
+	}
+	for _, b := range body.Blocks() {
+		labels := b.Labels()
+		if b.Type() != parts[0] || len(parts) < 1+len(labels) || !equalLabels(labels, parts[1:1+len(labels)]) {
+			continue
+		}
+		if found, n := resolveBodyDepth(b.Body(), parts[1+len(labels):], depth+1); found != nil {
+			return found, n
+		}
+	}
+	return nil, 0
+}
+
+// findBlock returns the block of the given type and labels, nil if not found.
+func findBlock(body *hclwrite.Body, typ string, labels []string) *hclwrite.Block {
+	for _, b := range body.Blocks() {
+		if b.Type() == typ && equalLabels(b.Labels(), labels) {
+			return b
+		}
+	}
+	return nil
+}
+
+func equalLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
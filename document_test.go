@@ -0,0 +1,125 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `# Managed by hand.
+region = "us-east-1" # default region
+
+module "vpc" {
+  source = "./vpc"
+
+  # The main range.
+  cidr = "10.1.0.0/16"
+}
+
+locals {
+  name = "web"
+}
+`
+
+func TestDocument(t *testing.T) {
+	doc, err := hcler.ParseDocument([]byte(testDocument), "main.tf")
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("module.vpc.cidr", "10.0.0.0/16"))
+	require.NoError(t, doc.Set("module.vpc.azs", hcler.List{"a", "b"}))
+	require.NoError(t, doc.Set("region", expr.Ref("var", "region")))
+	require.NoError(t, doc.Set("locals.tags", hcler.Map{"env": "prod"}))
+	require.NoError(t, doc.Delete("locals.name"))
+
+	assert.Equal(t, `# Managed by hand.
+region = var.region # default region
+
+module "vpc" {
+  source = "./vpc"
+
+  # The main range.
+  cidr = "10.0.0.0/16"
+  azs = ["a", "b"]
+}
+
+locals {
+  tags = { env = "prod" }
+}
+`, string(doc.Bytes()))
+}
+
+func TestDocumentPreserveBytes(t *testing.T) {
+	const src = "a   =    1\nblk \"l\" {\n      c = 1 # hi\n}\n"
+
+	doc, err := hcler.ParseDocument([]byte(src), "main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, src, string(doc.Bytes()))
+
+	require.NoError(t, doc.Set("blk.l.d", 2))
+	require.NoError(t, doc.Set("a", 3))
+	assert.Equal(t, "a   =    3\nblk \"l\" {\n      c = 1 # hi\n  d = 2\n}\n", string(doc.Bytes()))
+
+	require.NoError(t, doc.Set("blk.l.sub", hcler.Block{Body: hcler.Map{"e": 4}}))
+	assert.Equal(t, "a   =    3\nblk \"l\" {\n      c = 1 # hi\n  d = 2\n\n  sub {\n    e = 4\n  }\n}\n", string(doc.Bytes()))
+}
+
+func TestDocumentSetString(t *testing.T) {
+	doc, err := hcler.ParseDocument([]byte(testDocument), "main.tf")
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("locals.name", "a\"b\\c\nd"))
+	assert.Contains(t, string(doc.Bytes()), `name = "a\"b\\c\nd"`)
+	_, err = hcler.ParseDocument(doc.Bytes(), "main.tf")
+	require.NoError(t, err)
+}
+
+func TestDocumentBlocks(t *testing.T) {
+	doc, err := hcler.ParseDocument([]byte(testDocument), "main.tf")
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("module", hcler.Block{Labels: []string{"vpc"}, Body: hcler.Map{"source": "./vpc2"}}))
+	require.NoError(t, doc.Delete("locals"))
+	require.NoError(t, doc.Set("module", hcler.Blocks{{Labels: []string{"db"}, Body: hcler.Map{"source": "./db", "size": 2}}}))
+
+	assert.Equal(t, `# Managed by hand.
+region = "us-east-1" # default region
+
+module "vpc" {
+  source = "./vpc2"
+}
+
+module "db" {
+  size   = 2
+  source = "./db"
+}
+`, string(doc.Bytes()))
+
+	require.NoError(t, doc.Set("module.db.size", 3))
+	require.NoError(t, doc.Delete("module.vpc"))
+	assert.Contains(t, string(doc.Bytes()), "size   = 3")
+	assert.NotContains(t, string(doc.Bytes()), "vpc")
+}
+
+func TestDocumentError(t *testing.T) {
+	_, err := hcler.ParseDocument([]byte("a = "), "main.tf")
+	require.Error(t, err)
+
+	doc, err := hcler.ParseDocument([]byte(testDocument), "main.tf")
+	require.NoError(t, err)
+
+	for _, path := range []string{"module.db.cidr", "locals.name.x", "resource.a"} {
+		err := doc.Set(path, 1)
+		assert.Equal(t, hcler.ErrPathNotFound, errors.Cause(err), path)
+	}
+	for _, path := range []string{"module.db", "module.vpc.missing", "missing"} {
+		err := doc.Delete(path)
+		assert.Equal(t, hcler.ErrPathNotFound, errors.Cause(err), path)
+	}
+	assert.Equal(t, hcler.ErrInvalidKey, errors.Cause(doc.Set("module.vpc.a b", 1)))
+	assert.Error(t, doc.Set("region", func() {}))
+	assert.Equal(t, testDocument, string(doc.Bytes()))
+}
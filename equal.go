@@ -0,0 +1,116 @@
+package hcler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Equal reports whether the given values encode the same once normalized:
+// keys are sorted, IMap, OrderedMap and Map are alike, as are List and
+// []interface{}, and numbers are compared by value, so 1, int64(1),
+// float64(1) and json.Number("1.0") are equal, while nil, bools and
+// strings are distinct.
+// Values which can't be encoded are never equal.
+func Equal(a, b interface{}) bool {
+	e := newCanonicalState()
+	defer e.release()
+
+	ca, err := e.canonicalString(a)
+	if err != nil {
+		return false
+	}
+	cb, err := e.canonicalString(b)
+	if err != nil {
+		return false
+	}
+	return ca == cb
+}
+
+// Hash returns a stable digest of the given value: the hex encoded
+// SHA-256 of its canonical encoding. Equal values have the same hash.
+func Hash(v interface{}) (string, error) {
+	e := newCanonicalState()
+	defer e.release()
+
+	if err := e.encode(v); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(e.buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// newCanonicalState returns an encodeState producing the canonical
+// encoding used by Equal, Hash and Diff.
+func newCanonicalState() *encodeState {
+	e := newEncodeState(nil)
+	e.sortKeys = true
+	e.canonical = true
+	return e
+}
+
+// canonicalString returns the canonical encoding of the given value.
+func (e *encodeState) canonicalString(v interface{}) (string, error) {
+	start := len(e.buf)
+	err := e.encode(v)
+	s := string(e.buf[start:])
+	e.buf = e.buf[:start]
+	return s, err
+}
+
+// encodeCanonicalBlocks encodes blocks, which are otherwise only
+// allowed in bodies, as their labels followed by their body.
+func (e *encodeState) encodeCanonicalBlocks(blocks Blocks) error {
+	e.buf = append(e.buf, '<')
+	for i, b := range blocks {
+		if i > 0 {
+			e.buf = append(e.buf, ", "...)
+		}
+		for _, l := range b.Labels {
+			e.buf = strconv.AppendQuote(e.buf, l)
+			e.buf = append(e.buf, ' ')
+		}
+		if err := e.encode(bodyValue(b.Body)); err != nil {
+			return err
+		}
+	}
+	e.buf = append(e.buf, '>')
+	return nil
+}
+
+// appendCanonicalScalar appends the canonical encoding of nil, bools
+// and numbers, built from their exact value so distinct values never
+// encode the same. Reports false for other values.
+func appendCanonicalScalar(dst []byte, v interface{}) ([]byte, bool, error) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), true, nil
+	case bool:
+		return strconv.AppendBool(dst, v), true, nil
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case json.Number:
+		s = string(v)
+	case *big.Float:
+		if v == nil {
+			return append(dst, "null"...), true, nil
+		}
+		s = v.Text('g', -1)
+	default:
+		// Integers are already canonical.
+		return dst, false, nil
+	}
+	// Keep enough precision for long JSON numbers.
+	f, ok := new(big.Float).SetPrec(256).SetString(s)
+	if !ok || f.IsInf() {
+		return nil, true, errors.Errorf("unsupported number %s", s)
+	}
+	return f.Append(dst, 'f', -1), true, nil
+}
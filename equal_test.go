@@ -0,0 +1,99 @@
+package hcler_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	for name, tc := range map[string]struct{ a, b interface{} }{
+		"maps": {
+			a: hcler.Map{"a": 1, "b": hcler.List{"x", 2.5}, "c": hcler.Map{"d": true}},
+			b: map[interface{}]interface{}{"c": map[interface{}]interface{}{"d": true}, "b": []interface{}{"x", json.Number("2.5")}, "a": int64(1)},
+		},
+		"ordered": {
+			a: hcler.OrderedMap{{Key: "b", Value: 2}, {Key: "a", Value: 1}},
+			b: hcler.Map{"a": 1, "b": 2},
+		},
+		"numbers": {
+			a: hcler.List{1, float64(2), json.Number("3.0"), json.Number("4e1"), big.NewFloat(5)},
+			b: hcler.List{json.Number("1"), int8(2), 3, 40, uint(5)},
+		},
+		"blocks": {
+			a: hcler.Map{"module": hcler.Block{Labels: []string{"vpc"}, Body: hcler.Map{"a": 1}}},
+			b: hcler.Map{"module": hcler.Blocks{{Labels: []string{"vpc"}, Body: hcler.OrderedMap{{Key: "a", Value: 1}}}}},
+		},
+		"decimals": {
+			a: hcler.List{json.Number("0.125"), float32(0.5), json.Number("1.5e-3")},
+			b: hcler.List{0.125, 0.5, 0.0015},
+		},
+		"expressions": {
+			a: hcler.Map{"ami": expr.Ref("var", "ami")},
+			b: hcler.Map{"ami": expr.Ref("var", "ami")},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, hcler.Equal(tc.a, tc.b))
+
+			ha, err := hcler.Hash(tc.a)
+			require.NoError(t, err)
+			hb, err := hcler.Hash(tc.b)
+			require.NoError(t, err)
+			assert.Equal(t, ha, hb)
+			assert.Len(t, ha, 64)
+		})
+	}
+}
+
+func TestNotEqual(t *testing.T) {
+	for name, tc := range map[string]struct{ a, b interface{} }{
+		"value":     {a: hcler.Map{"a": 1}, b: hcler.Map{"a": 2}},
+		"key":       {a: hcler.Map{"a": 1}, b: hcler.Map{"b": 1}},
+		"number":    {a: 1, b: json.Number("1.5")},
+		"decimals":  {a: 0.121, b: 0.124},
+		"precision": {a: json.Number("0.1000000000000000000001"), b: 0.1},
+		"nil":       {a: nil, b: ""},
+		"bool":      {a: true, b: "1"},
+		"false":     {a: false, b: 0},
+		"list":      {a: hcler.List{1, 2}, b: hcler.List{2, 1}},
+		"labels":    {a: hcler.Block{Labels: []string{"a"}}, b: hcler.Block{Labels: []string{"b"}}},
+		"map_list":  {a: hcler.Map{}, b: hcler.List{}},
+		"expr":      {a: expr.Ref("var", "a"), b: "var.a"},
+		"encodable": {a: func() {}, b: func() {}},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.False(t, hcler.Equal(tc.a, tc.b))
+
+			ha, errA := hcler.Hash(tc.a)
+			hb, errB := hcler.Hash(tc.b)
+			if errA == nil && errB == nil {
+				assert.NotEqual(t, ha, hb)
+			}
+		})
+	}
+
+	_, err := hcler.Hash(func() {})
+	assert.Error(t, err)
+}
+
+func TestHashStable(t *testing.T) {
+	m := hcler.Map{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		m[k] = hcler.Map{k: k}
+	}
+	expect, err := hcler.Hash(m)
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		got, err := hcler.Hash(m)
+		require.NoError(t, err)
+		assert.Equal(t, expect, got)
+	}
+}
@@ -0,0 +1,231 @@
+// Package expr provides constructors for HCL2 expressions.
+//
+// Every constructor returns an Expr which implements hcler.Encoder,
+// so expressions can be used anywhere hcler.Encode accepts a value.
+// Constructors validate their input; errors are reported when the
+// expression is encoded.
+package expr
+
+import (
+	"strings"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+)
+
+// Expr is an HCL2 expression.
+type Expr struct {
+	s   string
+	err error
+
+	// compound is set when the expression needs to be wrapped
+	// in parentheses when used as an operand.
+	compound bool
+}
+
+// EncodeHCL implements the hcler.Encoder interface.
+// The zero value, not built by a constructor, fails to encode.
+func (e Expr) EncodeHCL() (string, error) {
+	if e.s == "" && e.err == nil {
+		return "", errors.New("empty expression")
+	}
+	return e.s, e.err
+}
+
+func checkIdent(name string) error {
+	if !hcler.IsIdentifier(name) {
+		return errors.Errorf("invalid identifier %q", name)
+	}
+	return nil
+}
+
+// operand encodes v for use inside a larger expression.
+func operand(v interface{}) (string, error) {
+	if e, ok := v.(Expr); ok && e.compound {
+		if e.err != nil {
+			return "", e.err
+		}
+		return "(" + e.s + ")", nil
+	}
+	return hcler.Encode(v)
+}
+
+func fail(err error) Expr {
+	return Expr{err: err}
+}
+
+// Ref references a named value and optionally some of its attributes.
+// i.e. Ref("var", "region") yields `var.region`.
+func Ref(name string, attrs ...string) Expr {
+	if err := checkIdent(name); err != nil {
+		return fail(err)
+	}
+	for _, a := range attrs {
+		if err := checkIdent(a); err != nil {
+			return fail(errors.Wrapf(err, "ref %q", name))
+		}
+	}
+	return Expr{s: strings.Join(append([]string{name}, attrs...), ".")}
+}
+
+// Bool yields the literal `true` or `false`.
+// Plain bools are encoded as "1" and "0" strings.
+func Bool(b bool) Expr {
+	if b {
+		return Expr{s: "true"}
+	}
+	return Expr{s: "false"}
+}
+
+// Attr accesses the attribute name of the given expression.
+func Attr(v interface{}, name string) Expr {
+	if err := checkIdent(name); err != nil {
+		return fail(err)
+	}
+	s, err := operand(v)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode attr target"))
+	}
+	return Expr{s: s + "." + name}
+}
+
+// Index accesses the element key of the given collection.
+func Index(collection, key interface{}) Expr {
+	s, err := operand(collection)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode index target"))
+	}
+	k, err := hcler.Encode(key)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode index key"))
+	}
+	return Expr{s: s + "[" + k + "]"}
+}
+
+// Call invokes the function name with the given arguments.
+func Call(name string, args ...interface{}) Expr {
+	if err := checkIdent(name); err != nil {
+		return fail(err)
+	}
+	elems := make([]string, 0, len(args))
+	for i, arg := range args {
+		s, err := hcler.Encode(arg)
+		if err != nil {
+			return fail(errors.Wrapf(err, "encode %s argument %d", name, i))
+		}
+		elems = append(elems, s)
+	}
+	return Expr{s: name + "(" + strings.Join(elems, ", ") + ")"}
+}
+
+// Cond yields `cond ? then : els`.
+func Cond(cond, then, els interface{}) Expr {
+	elems := make([]string, 0, 3)
+	for _, v := range []interface{}{cond, then, els} {
+		s, err := operand(v)
+		if err != nil {
+			return fail(errors.Wrap(err, "encode conditional"))
+		}
+		elems = append(elems, s)
+	}
+	return Expr{s: elems[0] + " ? " + elems[1] + " : " + elems[2], compound: true}
+}
+
+var binaryOps = map[string]struct{}{
+	"+": {}, "-": {}, "*": {}, "/": {}, "%": {},
+	"==": {}, "!=": {}, "<": {}, "<=": {}, ">": {}, ">=": {},
+	"&&": {}, "||": {},
+}
+
+// Binary yields `a op b`. op must be one of the HCL2 arithmetic,
+// comparison or logical operators.
+func Binary(a interface{}, op string, b interface{}) Expr {
+	if _, ok := binaryOps[op]; !ok {
+		return fail(errors.Errorf("invalid operator %q", op))
+	}
+	left, err := operand(a)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode left operand"))
+	}
+	right, err := operand(b)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode right operand"))
+	}
+	return Expr{s: left + " " + op + " " + right, compound: true}
+}
+
+// Not yields `!v`.
+func Not(v interface{}) Expr {
+	s, err := operand(v)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode not operand"))
+	}
+	return Expr{s: "!" + s}
+}
+
+// ForSpec describes a for expression.
+type ForSpec struct {
+	Key   string      // Optional key/index iteration variable.
+	Value string      // Value iteration variable.
+	In    interface{} // Collection to iterate over.
+
+	KeyResult interface{} // When set, the expression yields an object.
+	Result    interface{}
+	If        interface{} // Optional filter.
+	Group     bool        // Group results by key. Only valid for objects.
+}
+
+// For yields a for expression, i.e. `[for v in coll : v.id]`
+// or `{for k, v in coll : k => v.id}` when KeyResult is set.
+func For(spec ForSpec) Expr {
+	if spec.Key != "" {
+		if err := checkIdent(spec.Key); err != nil {
+			return fail(errors.Wrap(err, "for key"))
+		}
+	}
+	if err := checkIdent(spec.Value); err != nil {
+		return fail(errors.Wrap(err, "for value"))
+	}
+	if spec.Group && spec.KeyResult == nil {
+		return fail(errors.New("for grouping requires a key result"))
+	}
+	in, err := hcler.Encode(spec.In)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode for collection"))
+	}
+	result, err := hcler.Encode(spec.Result)
+	if err != nil {
+		return fail(errors.Wrap(err, "encode for result"))
+	}
+
+	var b strings.Builder
+	open, closing := "[", "]"
+	if spec.KeyResult != nil {
+		open, closing = "{", "}"
+	}
+	_, _ = b.WriteString(open + "for ")
+	if spec.Key != "" {
+		_, _ = b.WriteString(spec.Key + ", ")
+	}
+	_, _ = b.WriteString(spec.Value + " in " + in + " : ")
+	if spec.KeyResult != nil {
+		key, err := hcler.Encode(spec.KeyResult)
+		if err != nil {
+			return fail(errors.Wrap(err, "encode for key result"))
+		}
+		_, _ = b.WriteString(key + " => ")
+	}
+	_, _ = b.WriteString(result)
+	if spec.Group {
+		_, _ = b.WriteString("...")
+	}
+	if spec.If != nil {
+		cond, err := hcler.Encode(spec.If)
+		if err != nil {
+			return fail(errors.Wrap(err, "encode for condition"))
+		}
+		_, _ = b.WriteString(" if " + cond)
+	}
+	_, _ = b.WriteString(closing)
+	return Expr{s: b.String()}
+}
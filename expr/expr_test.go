@@ -0,0 +1,104 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Make sure that expressions implements the hcler.Encoder interface.
+var _ hcler.Encoder = expr.Expr{}
+
+func assertExpr(t *testing.T, expect string, v interface{}) {
+	t.Helper()
+
+	got, err := hcler.Encode(v)
+	require.NoError(t, err)
+	assert.Equal(t, expect, got)
+}
+
+func assertExprError(t *testing.T, v interface{}) {
+	t.Helper()
+
+	_, err := hcler.Encode(v)
+	require.Error(t, err)
+}
+
+func TestExpr(t *testing.T) {
+	t.Run("ref", func(t *testing.T) {
+		assertExpr(t, "var.region", expr.Ref("var", "region"))
+		assertExpr(t, "count", expr.Ref("count"))
+		assertExpr(t, "aws_instance.web.id", expr.Ref("aws_instance", "web", "id"))
+		assertExpr(t, "module.vpc.id", expr.Attr(expr.Ref("module", "vpc"), "id"))
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		assertExpr(t, "true", expr.Bool(true))
+		assertExpr(t, "false", expr.Bool(false))
+		assertExpr(t, "!true", expr.Not(expr.Bool(true)))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		assertExpr(t, `var.tags["Name"]`, expr.Index(expr.Ref("var", "tags"), "Name"))
+		assertExpr(t, "var.subnets[0]", expr.Index(expr.Ref("var", "subnets"), 0))
+		assertExpr(t, "(var.a ? var.b : var.c)[0]", expr.Index(expr.Cond(expr.Ref("var", "a"), expr.Ref("var", "b"), expr.Ref("var", "c")), 0))
+	})
+
+	t.Run("call", func(t *testing.T) {
+		assertExpr(t, "timestamp()", expr.Call("timestamp"))
+		assertExpr(t, `lookup(var.amis, var.region, "ami-0")`, expr.Call("lookup", expr.Ref("var", "amis"), expr.Ref("var", "region"), "ami-0"))
+		assertExpr(t, `concat([ "a" ], var.b)`, expr.Call("concat", hcler.List{"a"}, expr.Ref("var", "b")))
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		assertExpr(t, `(var.env == "prod") ? 3 : 1`, expr.Cond(expr.Binary(expr.Ref("var", "env"), "==", "prod"), 3, 1))
+		assertExpr(t, "(var.a + 1) * 2", expr.Binary(expr.Binary(expr.Ref("var", "a"), "+", 1), "*", 2))
+		assertExpr(t, "!var.enabled", expr.Not(expr.Ref("var", "enabled")))
+	})
+
+	t.Run("for", func(t *testing.T) {
+		assertExpr(t, "[for s in var.subnets : s.id]", expr.For(expr.ForSpec{
+			Value:  "s",
+			In:     expr.Ref("var", "subnets"),
+			Result: expr.Ref("s", "id"),
+		}))
+		assertExpr(t, "{for k, v in var.tags : k => v if v != null}", expr.For(expr.ForSpec{
+			Key:       "k",
+			Value:     "v",
+			In:        expr.Ref("var", "tags"),
+			KeyResult: expr.Ref("k"),
+			Result:    expr.Ref("v"),
+			If:        expr.Binary(expr.Ref("v"), "!=", expr.Ref("null")),
+		}))
+		assertExpr(t, "{for i in var.instances : i.az => i.id...}", expr.For(expr.ForSpec{
+			Value:     "i",
+			In:        expr.Ref("var", "instances"),
+			KeyResult: expr.Ref("i", "az"),
+			Result:    expr.Ref("i", "id"),
+			Group:     true,
+		}))
+	})
+
+	t.Run("nested_in_map", func(t *testing.T) {
+		assertExpr(t, "{ ami = var.ami }", hcler.Map{"ami": expr.Ref("var", "ami")})
+	})
+}
+
+func TestExprError(t *testing.T) {
+	assertExprError(t, expr.Ref("var", "1region"))
+	assertExprError(t, expr.Ref("va r"))
+	assertExprError(t, expr.Call("look up"))
+	assertExprError(t, expr.Binary(1, "=", 2))
+	assertExprError(t, expr.For(expr.ForSpec{Value: "v", In: hcler.List{}, Result: 1, Group: true}))
+	assertExprError(t, hcler.Map{"ami": expr.Ref("var", "")})
+	assertExprError(t, expr.Expr{})
+	assertExprError(t, hcler.Map{"a": expr.Expr{}})
+	assertExprError(t, expr.Call("upper", expr.Expr{}))
+
+	// Errors propagate through nesting.
+	assertExprError(t, expr.Call("upper", expr.Ref("var", "b@d")))
+	assertExprError(t, expr.Cond(expr.Ref("-"), 1, 2))
+}
@@ -1,10 +1,17 @@
 package hcler
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
-	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -18,139 +25,469 @@ type Encoder interface {
 // Map .
 type Map map[string]interface{}
 
-var re = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+// ErrInvalidKey is returned in strict mode when a key is not a valid identifier.
+var ErrInvalidKey = errors.New("invalid key")
 
-// escapeKey look if the given key contains something
-// else than alphnum _ -, and wraps it in double quote if.
-func escapeKey(k string) string {
-	if !re.MatchString(k) {
-		return `"` + k + `"`
+// IsIdentifier reports whether s is a valid HCL identifier:
+// an ID_Start character or an underscore, followed by
+// ID_Continue characters or dashes.
+func IsIdentifier(s string) bool {
+	for i, r := range s {
+		if i == 0 {
+			if r != '_' && !isIDStart(r) {
+				return false
+			}
+			continue
+		}
+		if r != '-' && !isIDContinue(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isIDStart(r rune) bool {
+	if r < unicode.MaxASCII {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+	}
+	return unicode.In(r, unicode.Letter, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+func isIDContinue(r rune) bool {
+	if r < unicode.MaxASCII {
+		return isIDStart(r) || '0' <= r && r <= '9' || r == '_'
 	}
-	return k
+	return isIDStart(r) ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+			!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// writeKey appends the given key, quoted unless it is a valid identifier.
+func (e *encodeState) writeKey(k string) error {
+	// A leading "for" would be parsed as a for expression.
+	if !e.quoteKeys && IsIdentifier(k) && k != "for" {
+		e.buf = append(e.buf, k...)
+		return nil
+	}
+	if e.strictKeys && !IsIdentifier(k) {
+		return errors.Wrapf(ErrInvalidKey, "%q", k)
+	}
+	e.buf = append(e.buf, '"')
+	e.buf = append(e.buf, quotedReplacer.Replace(k)...)
+	e.buf = append(e.buf, '"')
+	return nil
 }
 
 // EncodeHCL implements the hcl.Encoder interface.
-// nolint: gosec
 func (m Map) EncodeHCL() (string, error) {
+	return encodeString(m, nil)
+}
+
+func (e *encodeState) encodeMap(m Map) error {
 	if len(m) == 0 {
-		return "{}", nil
+		e.buf = append(e.buf, "{}"...)
+		return nil
 	}
-	var b strings.Builder
+	if err := e.enter(m, 0); err != nil {
+		return err
+	}
+	defer e.leave()
 
-	// Can't fail beside out of memory error.
-	_, _ = b.WriteString("{ ")
-	for k, v := range m {
-		valueString, err := Encode(v)
-		if err != nil {
-			return "", errors.Wrapf(err, "encode %q", k)
+	start := e.openObject()
+	if err := e.writeEntries(m); err != nil {
+		return err
+	}
+	e.closeObject(start)
+	return nil
+}
+
+// writeEntries appends the entries of the given map, sorted by key
+// if requested.
+func (e *encodeState) writeEntries(m Map) error {
+	if !e.sortKeys {
+		for k, v := range m {
+			if err := e.writeEntry(k, v); err != nil {
+				return err
+			}
 		}
-		// Quotes mandatory with special chars, so always set them.
-		_, _ = b.WriteString(escapeKey(k))
-		_, _ = b.WriteString(" = ")
-		_, _ = b.WriteString(valueString)
-		_, _ = b.WriteString(", ")
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := e.writeEntry(k, m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntry appends `key = value` to the object being encoded.
+func (e *encodeState) writeEntry(k string, v interface{}) error {
+	if err := e.beginEntry(k); err != nil {
+		return err
+	}
+	e.pushKey(k)
+	err := e.encode(v)
+	e.popPath()
+	if err != nil {
+		return errors.Wrapf(err, "encode %q", k)
+	}
+	e.endEntry()
+	return nil
+}
+
+// openObject starts an object and returns its offset for closeObject.
+func (e *encodeState) openObject() int {
+	start := len(e.buf)
+	e.level++
+	if e.indent != "" {
+		e.buf = append(e.buf, '{')
+	} else {
+		e.buf = append(e.buf, "{ "...)
 	}
-	return strings.TrimSuffix(b.String(), ", ") + " }", nil
+	return start
 }
 
+// beginEntry appends the `key = ` part of an object entry.
+func (e *encodeState) beginEntry(k string) error {
+	e.newline()
+	if err := e.writeKey(k); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, " = "...)
+	return nil
+}
+
+// newline starts a new indented line when pretty printing.
+func (e *encodeState) newline() {
+	if e.indent == "" {
+		return
+	}
+	if e.buf[len(e.buf)-1] != '\n' {
+		e.buf = append(e.buf, '\n')
+	}
+	for i := 0; i < e.level; i++ {
+		e.buf = append(e.buf, e.indent...)
+	}
+}
+
+// endEntry terminates an object entry.
+func (e *encodeState) endEntry() {
+	// Values ending with a newline (i.e. heredocs) are already
+	// terminated and can't be followed by a comma.
+	if e.indent == "" && e.buf[len(e.buf)-1] != '\n' {
+		e.buf = append(e.buf, ", "...)
+	}
+}
+
+// closeObject terminates the object started at the given offset.
+func (e *encodeState) closeObject(start int) {
+	e.level--
+	switch {
+	case len(e.buf) == start+len("{ ") && e.indent == "", len(e.buf) == start+len("{"):
+		e.buf = append(e.buf[:start], "{}"...)
+	case e.indent != "":
+		e.newline()
+		e.buf = append(e.buf, '}')
+	default:
+		e.buf = append(bytes.TrimSuffix(e.buf, []byte(", ")), " }"...)
+	}
+}
+
+// openList starts a list.
+func (e *encodeState) openList() {
+	e.level++
+	if e.indent != "" {
+		e.buf = append(e.buf, '[')
+	} else {
+		e.buf = append(e.buf, "[ "...)
+	}
+}
+
+// endElem terminates a list element.
+func (e *encodeState) endElem() {
+	if e.indent != "" {
+		e.buf = append(e.buf, ',')
+	} else {
+		e.buf = append(e.buf, ", "...)
+	}
+}
+
+// closeList terminates a non-empty list.
+func (e *encodeState) closeList() {
+	e.level--
+	if e.indent != "" {
+		e.newline()
+		e.buf = append(e.buf, ']')
+		return
+	}
+	e.buf = append(e.buf[:len(e.buf)-len(", ")], " ]"...)
+}
+
+// Rune is encoded as a one character string.
+// Plain runes, aka int32, are encoded as numbers.
+type Rune rune
+
+// Runes is encoded as a string.
+// Plain rune slices, aka []int32, are encoded as lists of numbers.
+type Runes []rune
+
+// Byte is encoded as a one character string.
+// Plain bytes, aka uint8, are encoded as numbers.
+type Byte byte
+
 // IMap .
 type IMap map[interface{}]interface{}
 
 // Map converts an hcl.IMap to a hcl.Map.
+// Fails with ErrKeyCollision if distinct keys convert to the same string.
 func (m IMap) Map() (Map, error) {
-	if len(m) == 0 {
-		return nil, nil
-	}
-	out := make(Map, len(m))
-	for k, v := range m {
-		s, err := toString(k, false)
-		if err != nil {
-			return nil, errors.Wrap(err, "toString map key")
-		}
-		out[s] = v
-	}
-	return out, nil
+	return m.MapWith(CollisionError)
 }
 
 // EncodeHCL implements the hcl.Encoder interface.
-// Stringifies the keys and defers to hcl.Map for encoding.
+// Stringifies the keys and encodes it as a hcl.Map.
 func (m IMap) EncodeHCL() (string, error) {
+	return encodeString(m, nil)
+}
+
+func (e *encodeState) encodeIMap(m IMap) error {
 	if len(m) == 0 {
-		return "{}", nil
+		e.buf = append(e.buf, "{}"...)
+		return nil
 	}
-	out, err := m.Map()
-	if err != nil {
-		return "", errors.Wrap(err, "convert to hcl.Map")
+	if err := e.enter(m, 0); err != nil {
+		return err
+	}
+	defer e.leave()
+
+	start := e.openObject()
+	if e.sortKeys || !hasOnlyStringKeys(m) {
+		out, err := stringifyKeys(m, e.collisions)
+		if err != nil {
+			return errors.Wrap(err, "convert to hcl.Map")
+		}
+		if err := e.writeEntries(out); err != nil {
+			return err
+		}
+		e.closeObject(start)
+		return nil
+	}
+	for k, v := range m {
+		if err := e.writeEntry(k.(string), v); err != nil {
+			return err
+		}
 	}
-	return out.EncodeHCL()
+	e.closeObject(start)
+	return nil
 }
 
 // List .
 type List []interface{}
 
 // EncodeHCL implements the hcl.Encoder interface.
-// nolint: gosec
 func (l List) EncodeHCL() (string, error) {
+	return encodeString(l, nil)
+}
+
+func (e *encodeState) encodeList(l List) error {
 	if len(l) == 0 {
-		return "[]", nil
+		e.buf = append(e.buf, "[]"...)
+		return nil
+	}
+	if err := e.enterList(l); err != nil {
+		return err
 	}
-	var b strings.Builder
+	defer e.leave()
 
-	// Can't fail beside out of memory error.
-	_, _ = b.WriteString("[ ")
-	for _, v := range l {
-		valueString, err := Encode(v)
+	e.openList()
+	for i, v := range l {
+		e.newline()
+		e.pushIndex(i)
+		err := e.encode(v)
+		e.popPath()
 		if err != nil {
-			return "", errors.Wrap(err, "encode list element")
+			return errors.Wrap(err, "encode list element")
 		}
-		_, _ = b.WriteString(valueString)
-		_, _ = b.WriteString(", ")
+		e.endElem()
 	}
-	return strings.TrimSuffix(b.String(), ", ") + " ]", nil
+	e.closeList()
+	return nil
 }
 
-// Encode implements the hcl.Encoder interface.
-func Encode(v interface{}) (string, error) {
+// Encode encodes the given value to HCL.
+func Encode(v interface{}, opts ...Option) (string, error) {
+	return encodeString(v, opts)
+}
+
+// AppendEncode appends the HCL encoding of the given value to dst
+// and returns the extended buffer. On error, dst is returned unchanged.
+func AppendEncode(dst []byte, v interface{}, opts ...Option) ([]byte, error) {
+	e := newEncodeState(opts)
+	defer e.release()
+
+	e.buf = dst
+	err := e.encode(v)
+	buf := e.buf
+	e.buf = nil
+	if err != nil {
+		return dst, err
+	}
+	return buf, nil
+}
+
+func encodeString(v interface{}, opts []Option) (string, error) {
+	e := newEncodeState(opts)
+	defer e.release()
+
+	if err := e.encode(v); err != nil {
+		return "", err
+	}
+	return string(e.buf), nil
+}
+
+func (e *encodeState) encode(v interface{}) error {
 	switch v := v.(type) {
-	case Encoder:
-		return v.EncodeHCL()
+	case nil:
+		if e.nilAsNull || e.canonical {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		e.buf = append(e.buf, `""`...)
+		return nil
+	case Map:
+		return e.encodeMap(v)
 	case map[string]interface{}:
-		return Map(v).EncodeHCL()
+		return e.encodeMap(v)
+	case IMap:
+		return e.encodeIMap(v)
 	case map[interface{}]interface{}:
-		return IMap(v).EncodeHCL()
+		return e.encodeIMap(v)
+	case OrderedMap:
+		return e.encodeOrderedMap(v)
+	case List:
+		return e.encodeList(v)
 	case []interface{}:
-		return List(v).EncodeHCL()
-	default:
-		s, err := toString(v, true)
+		return e.encodeList(v)
+	case Block:
+		if e.canonical {
+			return e.encodeCanonicalBlocks(Blocks{v})
+		}
+		return ErrBlockContext
+	case Blocks:
+		if e.canonical {
+			return e.encodeCanonicalBlocks(v)
+		}
+		return ErrBlockContext
+	case Encoder:
+		s, err := v.EncodeHCL()
 		if err != nil {
-			return "", err
+			return err
+		}
+		e.buf = append(e.buf, s...)
+		return nil
+	default:
+		if e.canonical {
+			if buf, ok, err := appendCanonicalScalar(e.buf, v); ok {
+				if err == nil {
+					e.buf = buf
+				}
+				return err
+			}
 		}
-		return s, nil
+		buf, err := appendString(e.buf, v, true)
+		if _, ok := err.(*UnsupportedTypeError); !ok {
+			if err == nil {
+				e.buf = buf
+			}
+			return err
+		}
+		if m, ok := v.(json.Marshaler); ok && e.jsonFallback {
+			return e.encodeJSON(m)
+		}
+		// Structs, typed collections and named types.
+		return e.encodeReflect(reflect.ValueOf(v))
 	}
 }
 
+// encodeJSON marshals the given value to JSON and encodes the result.
+func (e *encodeState) encodeJSON(m json.Marshaler) error {
+	buf, err := m.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "marshal json %T", m)
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return errors.Wrapf(err, "decode json %T", m)
+	}
+	return e.encode(v)
+}
+
+var jsonNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
 // toString tries to convert the value to string.
+// encoding.TextMarshaler is preferred over fmt.Stringer.
 // If nil, returns "".
-// nolint: gocyclo
 func toString(v interface{}, escape bool) (string, error) {
+	buf, err := appendString(nil, v, escape)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// appendString is the appending version of toString.
+// nolint: gocyclo
+func appendString(dst []byte, v interface{}, escape bool) ([]byte, error) {
 	if v == nil {
 		if escape {
-			return `""`, nil
+			return append(dst, `""`...), nil
 		}
-		return "", nil
+		return dst, nil
 	}
 	var out string
 	switch v := v.(type) {
 	case string:
 		out = v
-	case rune: // aka int32.
+	case Rune:
 		out = string(v)
-	case []rune:
+	case Runes:
 		out = string(v)
-	case byte: // aka uint8
+	case Byte:
 		out = string(v)
 	case []byte:
 		out = string(v)
+	case *big.Int:
+		if v == nil {
+			return appendString(dst, nil, escape)
+		}
+		return v.Append(dst, 10), nil
+	case *big.Float:
+		if v == nil {
+			return appendString(dst, nil, escape)
+		}
+		if v.IsInf() {
+			return nil, errors.Errorf("unsupported infinite number %s", v)
+		}
+		return v.Append(dst, 'f', -1), nil
+	case json.Number:
+		if !jsonNumberRe.MatchString(string(v)) {
+			return nil, errors.Errorf("invalid json number %q", string(v))
+		}
+		return append(dst, v...), nil
+	case encoding.TextMarshaler:
+		buf, err := v.MarshalText()
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal text %T", v)
+		}
+		out = string(buf)
 	case fmt.Stringer:
 		out = v.String()
 	case error:
@@ -162,49 +499,77 @@ func toString(v interface{}, escape bool) (string, error) {
 			out = "0"
 		}
 	case int:
-		out = strconv.FormatInt(int64(v), 10)
-		escape = false
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int8:
-		out = strconv.FormatInt(int64(v), 10)
-		escape = false
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int16:
-		out = strconv.FormatInt(int64(v), 10)
-		escape = false
+		return strconv.AppendInt(dst, int64(v), 10), nil
+	case int32: // aka rune.
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int64:
-		out = strconv.FormatInt(v, 10)
-		escape = false
+		return strconv.AppendInt(dst, v, 10), nil
 	case uint:
-		out = strconv.FormatUint(uint64(v), 10)
-		escape = false
+		return strconv.AppendUint(dst, uint64(v), 10), nil
+	case uint8: // aka byte.
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint16:
-		out = strconv.FormatUint(uint64(v), 10)
-		escape = false
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint32:
-		out = strconv.FormatUint(uint64(v), 10)
-		escape = false
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint64:
-		out = strconv.FormatUint(v, 10)
-		escape = false
+		return strconv.AppendUint(dst, v, 10), nil
 	case uintptr:
-		out = strconv.FormatUint(uint64(v), 10)
-		escape = false
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return nil, errors.Errorf("unsupported non-finite number %v", v)
+		}
 		if v1 := int64(v); float32(v1) == v {
-			return toString(v1, escape)
+			return strconv.AppendInt(dst, v1, 10), nil
 		}
-		out = strconv.FormatFloat(float64(v), 'f', 2, 64)
-		escape = false
+		return strconv.AppendFloat(dst, float64(v), 'g', -1, 32), nil
 	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errors.Errorf("unsupported non-finite number %v", v)
+		}
 		if v1 := int64(v); float64(v1) == v {
-			return toString(v1, escape)
+			return strconv.AppendInt(dst, v1, 10), nil
 		}
-		out = strconv.FormatFloat(v, 'f', 2, 64)
-		escape = false
+		return strconv.AppendFloat(dst, v, 'g', -1, 64), nil
+	case complex64, complex128:
+		return nil, errors.Errorf("unsupported type %T: HCL has no complex numbers", v)
 	default:
-		return "", errors.Errorf("unsupported type %T", v)
+		return nil, &UnsupportedTypeError{Type: reflect.TypeOf(v)}
 	}
+	return appendQuote(dst, out, escape), nil
+}
+
+// appendQuote appends s, wrapped in double quotes if escape is set.
+// Quotes, backslashes and control characters are escaped, template
+// sequences are left as is so strings can hold interpolations.
+func appendQuote(dst []byte, s string, escape bool) []byte {
 	if !escape {
-		return out, nil
+		return append(dst, s...)
+	}
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if c < 0x20 || c == 0x7f {
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+				continue
+			}
+			dst = append(dst, c)
+		}
 	}
-	return `"` + out + `"`, nil
+	return append(dst, '"')
 }
@@ -390,3 +390,179 @@ func TestIMapConvertion(t *testing.T) {
 		assert.Equal(t, expect, m2)
 	})
 }
+
+func TestEncodeKeys(t *testing.T) {
+	t.Run("identifiers", func(t *testing.T) {
+		for _, k := range []string{"foo", "_foo", "foo-bar", "foo_1", "héllo", "日本"} {
+			assert.True(t, hcler.IsIdentifier(k), k)
+			assertEncoding([]string{`{ ` + k + ` = 1 }`}, hcler.Map{k: 1})(t)
+		}
+	})
+	t.Run("non_identifiers", func(t *testing.T) {
+		for k, expect := range map[string]string{
+			"":         `{ "" = 1 }`,
+			"1foo":     `{ "1foo" = 1 }`,
+			"-foo":     `{ "-foo" = 1 }`,
+			"foo.bar":  `{ "foo.bar" = 1 }`,
+			`a"b`:      `{ "a\"b" = 1 }`,
+			"${a}":     `{ "$${a}" = 1 }`,
+			"foo bar":  `{ "foo bar" = 1 }`,
+			"foo\nbar": `{ "foo\nbar" = 1 }`,
+		} {
+			assert.False(t, hcler.IsIdentifier(k), k)
+			assertEncoding([]string{expect}, hcler.Map{k: 1})(t)
+		}
+	})
+	t.Run("for_keyword", func(t *testing.T) {
+		assert.True(t, hcler.IsIdentifier("for"))
+		assertEncoding([]string{`{ "for" = 1 }`}, hcler.Map{"for": 1})(t)
+	})
+	t.Run("strict", func(t *testing.T) {
+		got, err := hcler.Encode(hcler.Map{"foo": hcler.Map{"bar-baz": 1}}, hcler.StrictKeys())
+		require.NoError(t, err)
+		assert.Equal(t, `{ foo = { bar-baz = 1 } }`, got)
+
+		_, err = hcler.Encode(hcler.Map{"foo": hcler.List{hcler.IMap{1: "bar"}}}, hcler.StrictKeys())
+		require.Error(t, err)
+		assert.Equal(t, hcler.ErrInvalidKey, errors.Cause(err))
+	})
+	t.Run("quote_all", func(t *testing.T) {
+		got, err := hcler.Encode(hcler.Map{"foo": hcler.IMap{"bar": 1}}, hcler.QuoteKeys())
+		require.NoError(t, err)
+		assert.Equal(t, `{ "foo" = { "bar" = 1 } }`, got)
+	})
+}
+
+type jsonOnly struct {
+	Name  string
+	Ports []int
+}
+
+func (j jsonOnly) MarshalJSON() ([]byte, error) {
+	if j.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	return []byte(fmt.Sprintf(`{"name":%q,"ports":[%d]}`, j.Name, j.Ports[0])), nil
+}
+
+func TestEncodeJSONFallback(t *testing.T) {
+	v := hcler.Map{"svc": jsonOnly{Name: "web", Ports: []int{80}}}
+
+	// Without fallback, the struct fields are encoded.
+	got, err := hcler.Encode(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{ svc = { Name = "web", Ports = [ 80 ] } }`, got)
+
+	got, err = hcler.Encode(v, hcler.JSONFallback())
+	require.NoError(t, err)
+	assert.Contains(t, []string{
+		`{ svc = { name = "web", ports = [ 80 ] } }`,
+		`{ svc = { ports = [ 80 ], name = "web" } }`,
+	}, got)
+
+	_, err = hcler.Encode(jsonOnly{}, hcler.JSONFallback())
+	require.Error(t, err)
+}
+
+type textAndJSON struct{ A int }
+
+func (textAndJSON) MarshalText() ([]byte, error) { return []byte("text"), nil }
+func (textAndJSON) MarshalJSON() ([]byte, error) { return []byte(`{"a":1}`), nil }
+
+type exprAndJSON struct{}
+
+func (exprAndJSON) EncodeHCL() (string, error)   { return "var.x", nil }
+func (exprAndJSON) MarshalJSON() ([]byte, error) { return []byte(`"json"`), nil }
+
+func TestEncodeJSONFallbackPrecedence(t *testing.T) {
+	type wrapper struct {
+		T textAndJSON `hcl:"t"`
+		E exprAndJSON `hcl:"e"`
+	}
+	for _, v := range []interface{}{
+		wrapper{},
+		map[string]interface{}{"t": textAndJSON{}, "e": exprAndJSON{}},
+	} {
+		got, err := hcler.Encode(v, hcler.JSONFallback(), hcler.SortKeys())
+		require.NoError(t, err)
+		assert.Contains(t, []string{`{ t = "text", e = var.x }`, `{ e = var.x, t = "text" }`}, got)
+	}
+	got, err := hcler.Encode(map[string]textAndJSON{"t": {}}, hcler.JSONFallback())
+	require.NoError(t, err)
+	assert.Equal(t, `{ t = "text" }`, got)
+}
+
+func TestAppendEncode(t *testing.T) {
+	m := hcler.Map{"foo": hcler.List{1, "bar", 4.5, hcler.IMap{"ok": int64(-1)}}}
+
+	got, err := hcler.AppendEncode([]byte("x = "), hcler.Map{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, `x = { foo = "bar" }`, string(got))
+
+	dst := []byte("x = ")
+	got, err = hcler.AppendEncode(dst, hcler.Map{"foo": unsafe.Pointer(t)})
+	require.Error(t, err)
+	assert.Equal(t, dst, got)
+
+	buf := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		if buf, err = hcler.AppendEncode(buf[:0], m); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !raceEnabled {
+		assert.Zero(t, allocs)
+	}
+	assertEncoding([]string{string(buf)}, m)(t)
+}
+
+func TestEncodeSortKeys(t *testing.T) {
+	type sorted struct {
+		M map[string]int `hcl:"m"`
+	}
+	got, err := hcler.Encode(hcler.Map{
+		"b": hcler.IMap{2: "two", 1: "one"},
+		"a": sorted{M: map[string]int{"y": 2, "x": 1}},
+		"c": map[string]interface{}{"z": 1, "_": 0},
+	}, hcler.SortKeys())
+	require.NoError(t, err)
+	assert.Equal(t, `{ a = { m = { x = 1, y = 2 } }, b = { "1" = "one", "2" = "two" }, c = { _ = 0, z = 1 } }`, got)
+}
+
+func TestEncodeRunes(t *testing.T) {
+	got, err := hcler.Encode(hcler.List{[]int32{65, 66}, hcler.Runes("AB")})
+	require.NoError(t, err)
+	assert.Equal(t, `[ [ 65, 66 ], "AB" ]`, got)
+}
+
+func TestEncodeIndent(t *testing.T) {
+	got, err := hcler.Encode(hcler.Map{
+		"name":   "web",
+		"ports":  []int{80, 443},
+		"empty":  hcler.Map{},
+		"nested": hcler.Map{"list": hcler.List{hcler.Map{"a": 1}, hcler.List{}}},
+		"script": hcler.NewTemplate("echo").Heredoc("EOT"),
+	}, hcler.SortKeys(), hcler.Indent("  "))
+	require.NoError(t, err)
+
+	expect := `{
+  empty = {}
+  name = "web"
+  nested = {
+    list = [
+      {
+        a = 1
+      },
+      [],
+    ]
+  }
+  ports = [
+    80,
+    443,
+  ]
+  script = <<EOT
+echo
+EOT
+}`
+	assert.Equal(t, expect, got)
+}
@@ -0,0 +1,245 @@
+package hcler
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrBlockContext is returned when a block is found outside of a body.
+var ErrBlockContext = errors.New("blocks are only allowed in bodies")
+
+// Block is an HCL block. In a body, the key holding the block is its type,
+// i.e. Map{"resource": Block{Labels: []string{"aws_instance", "web"}, Body: Map{...}}}.
+type Block struct {
+	Labels []string
+	Body   interface{} // Map-like value, nil for an empty block.
+}
+
+// Blocks are repeated blocks of the same type.
+type Blocks []Block
+
+// ToHCLWrite builds a hclwrite.File from the given body.
+// The body is a Map-like value where Block and Blocks values are
+// encoded as blocks and others as attributes.
+func ToHCLWrite(v interface{}, opts ...Option) (*hclwrite.File, error) {
+	f := hclwrite.NewEmptyFile()
+	if err := ToBody(f.Body(), v, opts...); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ToBody appends the attributes and blocks of the given body to dst.
+func ToBody(dst *hclwrite.Body, v interface{}, opts ...Option) error {
+	e := newEncodeState(opts)
+	defer e.release()
+	return e.writeBody(dst, v)
+}
+
+// EncodeBody encodes the given body as a formatted HCL document.
+func EncodeBody(v interface{}, opts ...Option) (string, error) {
+	f, err := ToHCLWrite(v, opts...)
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.Format(f.Bytes())), nil
+}
+
+// bodyEntries lists the entries of a Map-like body, sorted by key
+// unless ordered.
+func (e *encodeState) bodyEntries(v interface{}) (Map, []string, error) {
+	var m Map
+	switch v := v.(type) {
+	case nil:
+	case OrderedMap:
+		return v.Map(), v.Keys(), nil
+	case Map:
+		m = v
+	case map[string]interface{}:
+		m = v
+	case IMap:
+		out, err := v.MapWith(e.collisions)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "convert to hcl.Map")
+		}
+		m = out
+	case map[interface{}]interface{}:
+		return e.bodyEntries(IMap(v))
+	default:
+		return nil, nil, errors.Errorf("unsupported body type %T", v)
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return m, keys, nil
+}
+
+func isBlock(v interface{}) bool {
+	switch v.(type) {
+	case Block, Blocks:
+		return true
+	}
+	return false
+}
+
+// writeBody appends the attributes, then the blocks, of the given body.
+func (e *encodeState) writeBody(dst *hclwrite.Body, v interface{}) error {
+	if v != nil {
+		if err := e.enterObject(v); err != nil {
+			return err
+		}
+		defer e.leave()
+	}
+	m, keys, err := e.bodyEntries(v)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if isBlock(m[k]) {
+			continue
+		}
+		if !IsIdentifier(k) {
+			return errors.Wrapf(ErrInvalidKey, "attribute %q", k)
+		}
+		e.pushKey(k)
+		toks, err := e.tokens(m[k])
+		e.popPath()
+		if err != nil {
+			return errors.Wrapf(err, "encode %q", k)
+		}
+		dst.SetAttributeRaw(k, toks)
+	}
+	for _, k := range keys {
+		var blocks Blocks
+		switch b := m[k].(type) {
+		case Block:
+			blocks = Blocks{b}
+		case Blocks:
+			blocks = b
+		default:
+			continue
+		}
+		if !IsIdentifier(k) {
+			return errors.Wrapf(ErrInvalidKey, "block type %q", k)
+		}
+		for _, b := range blocks {
+			e.pushKey(k)
+			err := e.writeBody(dst.AppendNewBlock(k, b.Labels).Body(), b.Body)
+			e.popPath()
+			if err != nil {
+				return errors.Wrapf(err, "encode block %q", k)
+			}
+		}
+	}
+	return nil
+}
+
+// tokens builds the tokens of the given attribute value.
+// Maps and lists are built structurally, other values are encoded
+// and lexed.
+func (e *encodeState) tokens(v interface{}) (hclwrite.Tokens, error) {
+	switch v := v.(type) {
+	case Block, Blocks:
+		return nil, ErrBlockContext
+	case Map, map[string]interface{}, IMap, map[interface{}]interface{}, OrderedMap:
+		if err := e.enterObject(v); err != nil {
+			return nil, err
+		}
+		defer e.leave()
+		m, keys, err := e.bodyEntries(v)
+		if err != nil {
+			return nil, err
+		}
+		attrs := make([]hclwrite.ObjectAttrTokens, 0, len(keys))
+		for _, k := range keys {
+			e.pushKey(k)
+			toks, err := e.tokens(m[k])
+			e.popPath()
+			if err != nil {
+				return nil, errors.Wrapf(err, "encode %q", k)
+			}
+			name, err := e.keyTokens(k)
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{Name: name, Value: toks})
+		}
+		return hclwrite.TokensForObject(attrs), nil
+	case List:
+		return e.tupleTokens(v)
+	case []interface{}:
+		return e.tupleTokens(v)
+	}
+	start := len(e.buf)
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	// The tokens reference their source, copy it out of the reused buffer.
+	src := append([]byte(nil), e.buf[start:]...)
+	e.buf = e.buf[:start]
+	return lexTokens(src)
+}
+
+func (e *encodeState) tupleTokens(l List) (hclwrite.Tokens, error) {
+	if len(l) > 0 {
+		if err := e.enterList(l); err != nil {
+			return nil, err
+		}
+		defer e.leave()
+	}
+	elems := make([]hclwrite.Tokens, 0, len(l))
+	for i, elem := range l {
+		e.pushIndex(i)
+		toks, err := e.tokens(elem)
+		e.popPath()
+		if err != nil {
+			return nil, errors.Wrap(err, "encode list element")
+		}
+		elems = append(elems, toks)
+	}
+	return hclwrite.TokensForTuple(elems), nil
+}
+
+// keyTokens builds the tokens of an object key.
+func (e *encodeState) keyTokens(k string) (hclwrite.Tokens, error) {
+	if !e.quoteKeys && IsIdentifier(k) && k != "for" {
+		return hclwrite.TokensForIdentifier(k), nil
+	}
+	if e.strictKeys && !IsIdentifier(k) {
+		return nil, errors.Wrapf(ErrInvalidKey, "%q", k)
+	}
+	return hclwrite.TokensForValue(cty.StringVal(k)), nil
+}
+
+// lexTokens converts the given HCL expression to hclwrite tokens.
+func lexTokens(src []byte) (hclwrite.Tokens, error) {
+	toks, diags := hclsyntax.LexExpression(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(diags, "lex %q", src)
+	}
+	out := make(hclwrite.Tokens, 0, len(toks))
+	prevEnd := 0
+	for _, tok := range toks {
+		if tok.Type == hclsyntax.TokenEOF {
+			break
+		}
+		out = append(out, &hclwrite.Token{
+			Type:         tok.Type,
+			Bytes:        tok.Bytes,
+			SpacesBefore: tok.Range.Start.Byte - prevEnd,
+		})
+		prevEnd = tok.Range.End.Byte
+	}
+	// The attribute brings its own newline, drop the one closing a trailing heredoc.
+	for len(out) > 0 && out[len(out)-1].Type == hclsyntax.TokenNewline {
+		out = out[:len(out)-1]
+	}
+	return out, nil
+}
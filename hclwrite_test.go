@@ -0,0 +1,141 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToHCLWrite(t *testing.T) {
+	body := hcler.Map{
+		"region": "us-east-1",
+		"resource": hcler.Block{
+			Labels: []string{"aws_instance", "web"},
+			Body: hcler.Map{
+				"ami":   expr.Ref("var", "ami"),
+				"count": 2,
+				"tags":  hcler.Map{"Name": "web", "team name": "core"},
+				"ebs_block_device": hcler.Blocks{
+					{Body: hcler.Map{"device_name": "/dev/sda"}},
+					{Body: hcler.Map{"device_name": "/dev/sdb"}},
+				},
+			},
+		},
+		"ports": hcler.List{80, 443},
+	}
+	f, err := hcler.ToHCLWrite(body)
+	require.NoError(t, err)
+
+	// The document can be edited structurally.
+	f.Body().Blocks()[0].Body().SetAttributeRaw("count", hclwrite.TokensForIdentifier("var.count"))
+
+	expect := `ports  = [80, 443]
+region = "us-east-1"
+resource "aws_instance" "web" {
+  ami   = var.ami
+  count = var.count
+  tags = {
+    Name        = "web"
+    "team name" = "core"
+  }
+  ebs_block_device {
+    device_name = "/dev/sda"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+}
+`
+	assert.Equal(t, expect, string(hclwrite.Format(f.Bytes())))
+
+	_, diags := hclsyntax.ParseConfig(f.Bytes(), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+}
+
+func TestEncodeBody(t *testing.T) {
+	got, err := hcler.EncodeBody(hcler.IMap{
+		"name":   hcler.NewTemplate("web-").Interp(expr.Ref("count", "index")),
+		"script": hcler.NewTemplate("echo hello\n").Heredoc("EOT"),
+		"empty":  hcler.Block{},
+		"nested": hcler.Block{Labels: []string{"a"}, Body: hcler.Map{
+			"list": hcler.List{hcler.Map{"x": 1}},
+		}},
+	})
+	require.NoError(t, err)
+	expect := `name   = "web-${count.index}"
+script = <<EOT
+echo hello
+EOT
+empty {
+}
+nested "a" {
+  list = [{
+    x = 1
+  }]
+}
+`
+	assert.Equal(t, expect, got)
+}
+
+func TestEncodeBodyStrings(t *testing.T) {
+	values := hcler.Map{
+		"quote":   "a\"b",
+		"slash":   `C:\dir`,
+		"lines":   "a\nb\tc\x01",
+		"nested":  hcler.List{hcler.Map{"q": `say "hi"`}},
+		"unicode": "héllo",
+	}
+	got, err := hcler.EncodeBody(values)
+	require.NoError(t, err)
+
+	f, diags := hclsyntax.ParseConfig([]byte(got), "", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), "%s: %s", got, diags.Error())
+	attrs, diags := f.Body.JustAttributes()
+	require.False(t, diags.HasErrors(), diags.Error())
+	require.Len(t, attrs, len(values))
+	for name, attr := range attrs {
+		v, diags := attr.Expr.Value(nil)
+		require.False(t, diags.HasErrors(), diags.Error())
+		back, err := hcler.FromCty(v)
+		require.NoError(t, err)
+		assert.True(t, hcler.Equal(values[name], back), "%s: %#v", name, back)
+	}
+}
+
+func TestToHCLWriteError(t *testing.T) {
+	cyclic := hcler.Map{}
+	cyclic["self"] = cyclic
+
+	for name, tc := range map[string]struct {
+		body  interface{}
+		cause error
+	}{
+		"block_in_object":   {hcler.Map{"a": hcler.Map{"b": hcler.Block{}}}, hcler.ErrBlockContext},
+		"block_in_list":     {hcler.Map{"a": hcler.List{hcler.Blocks{}}}, hcler.ErrBlockContext},
+		"invalid_attribute": {hcler.Map{"a b": 1}, hcler.ErrInvalidKey},
+		"invalid_block":     {hcler.Map{"1a": hcler.Block{}}, hcler.ErrInvalidKey},
+		"cycle":             {cyclic, hcler.ErrCycle},
+		"block_cycle":       {hcler.Map{"b": hcler.Block{Body: cyclic}}, hcler.ErrCycle},
+		"value":             {hcler.Map{"a": expr.Ref("-")}, nil},
+		"body_type":         {hcler.List{}, nil},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			_, err := hcler.ToHCLWrite(tc.body)
+			require.Error(t, err)
+			if tc.cause != nil {
+				assert.Equal(t, tc.cause, errors.Cause(err))
+			}
+		})
+	}
+
+	_, err := hcler.Encode(hcler.Map{"a": hcler.Block{}})
+	assert.Equal(t, hcler.ErrBlockContext, errors.Cause(err))
+}
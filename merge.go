@@ -0,0 +1,218 @@
+package hcler
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrMergeConflict is returned when merging values of different kinds,
+// i.e. a map and a list, with the ConflictError strategy.
+var ErrMergeConflict = errors.New("merge conflict")
+
+// ListStrategy tells how Merge combines lists.
+type ListStrategy int
+
+// List strategies.
+const (
+	ListReplace    ListStrategy = iota // The overlay list replaces the base one.
+	ListAppend                         // The overlay elements are appended to the base ones.
+	ListMergeByKey                     // Map elements with the same Merger.ListKey value are merged, others appended.
+)
+
+// ConflictStrategy tells how Merge handles values of different kinds.
+// Scalars never conflict, the overlay value wins.
+type ConflictStrategy int
+
+// Conflict strategies.
+const (
+	ConflictOverride ConflictStrategy = iota // The overlay value wins.
+	ConflictKeep                             // The base value is kept.
+	ConflictError                            // Fail with ErrMergeConflict.
+)
+
+// Merger deep merges Map-like trees. The zero value replaces lists
+// and lets overlays win conflicts.
+type Merger struct {
+	Lists     ListStrategy
+	ListKey   string // Key identifying list elements for ListMergeByKey, i.e. "name".
+	Conflicts ConflictStrategy
+}
+
+// Merge deep merges the given overlays, in order, on top of base
+// using the default Merger.
+func Merge(base interface{}, overlays ...interface{}) (interface{}, error) {
+	return Merger{}.Merge(base, overlays...)
+}
+
+// Merge deep merges the given overlays, in order, on top of base.
+//
+// Map, IMap and OrderedMap are merged key by key. The result is an OrderedMap
+// if the base is one, new keys being appended, a Map otherwise.
+// The inputs are not modified, the result may share sub-trees with them.
+func (mg Merger) Merge(base interface{}, overlays ...interface{}) (interface{}, error) {
+	e := newEncodeState(nil)
+	defer e.release()
+
+	out := base
+	for i, overlay := range overlays {
+		var err error
+		if out, err = mg.merge(e, out, overlay); err != nil {
+			return nil, errors.Wrapf(err, "merge overlay %d", i)
+		}
+	}
+	return out, nil
+}
+
+func (mg Merger) merge(e *encodeState, base, overlay interface{}) (interface{}, error) {
+	baseObj, baseIsObj, err := objectEntries(base)
+	if err != nil {
+		return nil, err
+	}
+	overlayObj, overlayIsObj, err := objectEntries(overlay)
+	if err != nil {
+		return nil, err
+	}
+	if baseIsObj && overlayIsObj {
+		if err := e.enterObject(overlay); err != nil {
+			return nil, err
+		}
+		defer e.leave()
+		return mg.mergeObjects(e, base, baseObj, overlayObj)
+	}
+
+	baseList, baseIsList := listValue(base)
+	overlayList, overlayIsList := listValue(overlay)
+	if baseIsList && overlayIsList {
+		return mg.mergeLists(e, baseList, overlayList)
+	}
+
+	if base == nil || (!baseIsObj && !baseIsList && !overlayIsObj && !overlayIsList) {
+		return overlay, nil
+	}
+	switch mg.Conflicts {
+	case ConflictKeep:
+		return base, nil
+	case ConflictError:
+		return nil, errors.Wrapf(ErrMergeConflict, "%s: %T and %T", e.pathString(), base, overlay)
+	default:
+		return overlay, nil
+	}
+}
+
+func (mg Merger) mergeObjects(e *encodeState, base interface{}, baseObj, overlayObj OrderedMap) (interface{}, error) {
+	out := make(OrderedMap, len(baseObj), len(baseObj)+len(overlayObj))
+	copy(out, baseObj)
+	for _, kv := range overlayObj {
+		v := kv.Value
+		if prev, ok := out.Get(kv.Key); ok {
+			e.pushKey(kv.Key)
+			merged, err := mg.merge(e, prev, v)
+			e.popPath()
+			if err != nil {
+				return nil, err
+			}
+			v = merged
+		}
+		out.Set(kv.Key, v)
+	}
+	if _, ok := base.(OrderedMap); ok {
+		return out, nil
+	}
+	return out.Map(), nil
+}
+
+func (mg Merger) mergeLists(e *encodeState, base, overlay List) (interface{}, error) {
+	switch mg.Lists {
+	case ListAppend:
+		out := make(List, 0, len(base)+len(overlay))
+		return append(append(out, base...), overlay...), nil
+	case ListMergeByKey:
+		out := append(make(List, 0, len(base)+len(overlay)), base...)
+		for _, elem := range overlay {
+			i := mg.listIndex(out, elem)
+			if i < 0 {
+				out = append(out, elem)
+				continue
+			}
+			e.pushIndex(i)
+			merged, err := mg.merge(e, out[i], elem)
+			e.popPath()
+			if err != nil {
+				return nil, err
+			}
+			out[i] = merged
+		}
+		return out, nil
+	default:
+		return overlay, nil
+	}
+}
+
+// listIndex returns the index of the element of l sharing the ListKey
+// value of elem, -1 if none.
+func (mg Merger) listIndex(l List, elem interface{}) int {
+	id, ok := mg.listID(elem)
+	if !ok {
+		return -1
+	}
+	for i, candidate := range l {
+		if cid, ok := mg.listID(candidate); ok && reflect.DeepEqual(id, cid) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (mg Merger) listID(elem interface{}) (interface{}, bool) {
+	obj, ok, err := objectEntries(elem)
+	if !ok || err != nil {
+		return nil, false
+	}
+	return obj.Get(mg.ListKey)
+}
+
+// objectEntries returns the entries of a map-like value, in order for
+// OrderedMap, sorted by key otherwise.
+func objectEntries(v interface{}) (OrderedMap, bool, error) {
+	var m Map
+	switch v := v.(type) {
+	case OrderedMap:
+		return v, true, nil
+	case Map:
+		m = v
+	case map[string]interface{}:
+		m = v
+	case IMap:
+		out, err := stringifyKeys(v, CollisionError)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "convert to hcl.Map")
+		}
+		m = out
+	case map[interface{}]interface{}:
+		return objectEntries(IMap(v))
+	default:
+		return nil, false, nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make(OrderedMap, 0, len(m))
+	for _, k := range keys {
+		out = append(out, KeyValue{Key: k, Value: m[k]})
+	}
+	return out, true, nil
+}
+
+func listValue(v interface{}) (List, bool) {
+	switch v := v.(type) {
+	case List:
+		return v, true
+	case []interface{}:
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,104 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	base := hcler.Map{
+		"region": "us-east-1",
+		"tags":   hcler.Map{"team": "infra", "env": "dev"},
+		"zones":  hcler.List{"a", "b"},
+	}
+	prod := map[interface{}]interface{}{
+		"tags":  map[interface{}]interface{}{"env": "prod"},
+		"zones": []interface{}{"c"},
+	}
+	override := hcler.Map{"region": "eu-west-1", "replicas": 3}
+
+	got, err := hcler.Merge(base, prod, override)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{
+		"region":   "eu-west-1",
+		"tags":     hcler.Map{"team": "infra", "env": "prod"},
+		"zones":    hcler.List{"c"},
+		"replicas": 3,
+	}, got)
+
+	// The inputs are left untouched.
+	assert.Equal(t, hcler.Map{"team": "infra", "env": "dev"}, base["tags"])
+
+	got, err = hcler.Merger{Lists: hcler.ListAppend}.Merge(base, prod)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.List{"a", "b", "c"}, got.(hcler.Map)["zones"])
+
+	got, err = hcler.Merge(nil, base)
+	require.NoError(t, err)
+	assert.Equal(t, base, got)
+}
+
+func TestMergeOrdered(t *testing.T) {
+	base := hcler.OrderedMap{{Key: "source", Value: "./vpc"}, {Key: "version", Value: "1.0.0"}}
+	got, err := hcler.Merge(base, hcler.Map{"version": "1.1.0", "providers": hcler.Map{"aws": "aws.west"}, "count": 1})
+	require.NoError(t, err)
+	assert.Equal(t, hcler.OrderedMap{
+		{Key: "source", Value: "./vpc"},
+		{Key: "version", Value: "1.1.0"},
+		{Key: "count", Value: 1},
+		{Key: "providers", Value: hcler.Map{"aws": "aws.west"}},
+	}, got)
+}
+
+func TestMergeListByKey(t *testing.T) {
+	mg := hcler.Merger{Lists: hcler.ListMergeByKey, ListKey: "name"}
+	base := hcler.Map{"ports": hcler.List{
+		hcler.Map{"name": "http", "port": 80},
+		hcler.Map{"name": "https", "port": 443},
+		"raw",
+	}}
+	overlay := hcler.Map{"ports": hcler.List{
+		hcler.Map{"name": "https", "port": 8443},
+		hcler.OrderedMap{{Key: "name", Value: "grpc"}, {Key: "port", Value: 50051}},
+		"raw",
+	}}
+	got, err := mg.Merge(base, overlay)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{"ports": hcler.List{
+		hcler.Map{"name": "http", "port": 80},
+		hcler.Map{"name": "https", "port": 8443},
+		"raw",
+		hcler.OrderedMap{{Key: "name", Value: "grpc"}, {Key: "port", Value: 50051}},
+		"raw",
+	}}, got)
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base := hcler.Map{"a": hcler.Map{"b": hcler.List{1}}}
+	overlay := hcler.Map{"a": hcler.Map{"b": "scalar"}}
+
+	got, err := hcler.Merge(base, overlay)
+	require.NoError(t, err)
+	assert.Equal(t, overlay, got)
+
+	got, err = hcler.Merger{Conflicts: hcler.ConflictKeep}.Merge(base, overlay)
+	require.NoError(t, err)
+	assert.Equal(t, base, got)
+
+	_, err = hcler.Merger{Conflicts: hcler.ConflictError}.Merge(base, overlay)
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrMergeConflict, errors.Cause(err))
+	assert.Equal(t, "merge overlay 0: a.b: hcler.List and string: merge conflict", err.Error())
+
+	_, err = hcler.Merge(hcler.Map{}, hcler.IMap{1: "a", "1": "b"})
+	assert.Equal(t, hcler.ErrKeyCollision, errors.Cause(err))
+
+	cyclic := hcler.Map{}
+	cyclic["self"] = cyclic
+	_, err = hcler.Merge(cyclic, cyclic)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+}
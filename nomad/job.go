@@ -0,0 +1,389 @@
+// Package nomad builds Nomad job specifications with hcler.
+package nomad
+
+import (
+	"sort"
+	"time"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+)
+
+// Job is a `job "name"` block.
+type Job struct {
+	Name        string
+	Region      string
+	Namespace   string
+	Type        string // service, batch, system or sysbatch.
+	Datacenters []string
+	Priority    int
+	Meta        map[string]string
+	Constraints []Constraint
+	Groups      []Group
+}
+
+// Block builds the `job` block.
+func (j Job) Block() (hcler.Block, error) {
+	if j.Name == "" {
+		return hcler.Block{}, errors.New("job name is required")
+	}
+	if len(j.Groups) == 0 {
+		return hcler.Block{}, errors.Errorf("job %q: at least one group is required", j.Name)
+	}
+	var body hcler.OrderedMap
+	setString(&body, "region", j.Region)
+	setString(&body, "namespace", j.Namespace)
+	setString(&body, "type", j.Type)
+	setStrings(&body, "datacenters", j.Datacenters)
+	setInt(&body, "priority", j.Priority)
+	setMeta(&body, j.Meta)
+	setConstraints(&body, j.Constraints)
+	groups := make(hcler.Blocks, 0, len(j.Groups))
+	for _, g := range j.Groups {
+		b, err := g.Block()
+		if err != nil {
+			return hcler.Block{}, errors.Wrapf(err, "job %q", j.Name)
+		}
+		groups = append(groups, b)
+	}
+	body.Set("group", groups)
+	return hcler.Block{Labels: []string{j.Name}, Body: body}, nil
+}
+
+// File renders the job specification as a hclwrite.File.
+func (j Job) File() (*hclwrite.File, error) {
+	b, err := j.Block()
+	if err != nil {
+		return nil, err
+	}
+	return hcler.ToHCLWrite(hcler.Map{"job": b})
+}
+
+// Encode renders the job specification as a formatted HCL document.
+func (j Job) Encode() (string, error) {
+	f, err := j.File()
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.Format(f.Bytes())), nil
+}
+
+// Group is a `group "name"` block.
+type Group struct {
+	Name        string
+	Count       int // 0 to use Nomad's default of 1.
+	Meta        map[string]string
+	Constraints []Constraint
+	Networks    []Network
+	Services    []Service
+	Tasks       []Task
+}
+
+// Block builds the `group` block.
+func (g Group) Block() (hcler.Block, error) {
+	if g.Name == "" {
+		return hcler.Block{}, errors.New("group name is required")
+	}
+	if len(g.Tasks) == 0 {
+		return hcler.Block{}, errors.Errorf("group %q: at least one task is required", g.Name)
+	}
+	var body hcler.OrderedMap
+	setInt(&body, "count", g.Count)
+	setMeta(&body, g.Meta)
+	setConstraints(&body, g.Constraints)
+	if len(g.Networks) > 0 {
+		networks := make(hcler.Blocks, 0, len(g.Networks))
+		for _, n := range g.Networks {
+			networks = append(networks, n.block())
+		}
+		body.Set("network", networks)
+	}
+	if err := setServices(&body, g.Services); err != nil {
+		return hcler.Block{}, errors.Wrapf(err, "group %q", g.Name)
+	}
+	tasks := make(hcler.Blocks, 0, len(g.Tasks))
+	for _, t := range g.Tasks {
+		b, err := t.Block()
+		if err != nil {
+			return hcler.Block{}, errors.Wrapf(err, "group %q", g.Name)
+		}
+		tasks = append(tasks, b)
+	}
+	body.Set("task", tasks)
+	return hcler.Block{Labels: []string{g.Name}, Body: body}, nil
+}
+
+// Task is a `task "name"` block.
+type Task struct {
+	Name        string
+	Driver      string
+	User        string
+	Config      interface{} // Map-like driver configuration.
+	Env         map[string]string
+	Meta        map[string]string
+	KillTimeout time.Duration
+	Constraints []Constraint
+	Templates   []Template
+	Resources   *Resources
+	Services    []Service
+}
+
+// Block builds the `task` block.
+func (t Task) Block() (hcler.Block, error) {
+	if t.Name == "" {
+		return hcler.Block{}, errors.New("task name is required")
+	}
+	if t.Driver == "" {
+		return hcler.Block{}, errors.Errorf("task %q: driver is required", t.Name)
+	}
+	var body hcler.OrderedMap
+	body.Set("driver", t.Driver)
+	setString(&body, "user", t.User)
+	setDuration(&body, "kill_timeout", t.KillTimeout)
+	if t.Config != nil {
+		body.Set("config", hcler.Block{Body: t.Config})
+	}
+	if len(t.Env) > 0 {
+		body.Set("env", hcler.Block{Body: stringMap(t.Env)})
+	}
+	setMeta(&body, t.Meta)
+	setConstraints(&body, t.Constraints)
+	if len(t.Templates) > 0 {
+		templates := make(hcler.Blocks, 0, len(t.Templates))
+		for _, tpl := range t.Templates {
+			templates = append(templates, tpl.block())
+		}
+		body.Set("template", templates)
+	}
+	if t.Resources != nil {
+		body.Set("resources", t.Resources.block())
+	}
+	if err := setServices(&body, t.Services); err != nil {
+		return hcler.Block{}, errors.Wrapf(err, "task %q", t.Name)
+	}
+	return hcler.Block{Labels: []string{t.Name}, Body: body}, nil
+}
+
+// Service is a `service` block.
+type Service struct {
+	Name     string
+	Port     string // Port label or number.
+	Provider string // consul or nomad.
+	Tags     []string
+	Checks   []Check
+}
+
+func (s Service) block() (hcler.Block, error) {
+	var body hcler.OrderedMap
+	setString(&body, "name", s.Name)
+	setString(&body, "port", s.Port)
+	setString(&body, "provider", s.Provider)
+	setStrings(&body, "tags", s.Tags)
+	if len(s.Checks) > 0 {
+		checks := make(hcler.Blocks, 0, len(s.Checks))
+		for _, c := range s.Checks {
+			if c.Type == "" {
+				return hcler.Block{}, errors.Errorf("service %q: check type is required", s.Name)
+			}
+			checks = append(checks, c.block())
+		}
+		body.Set("check", checks)
+	}
+	return hcler.Block{Body: body}, nil
+}
+
+// Check is a service `check` block.
+type Check struct {
+	Name     string
+	Type     string // http, tcp, grpc or script.
+	Port     string
+	Path     string
+	Protocol string
+	Command  string
+	Args     []string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+func (c Check) block() hcler.Block {
+	var body hcler.OrderedMap
+	setString(&body, "name", c.Name)
+	body.Set("type", c.Type)
+	setString(&body, "port", c.Port)
+	setString(&body, "path", c.Path)
+	setString(&body, "protocol", c.Protocol)
+	setString(&body, "command", c.Command)
+	setStrings(&body, "args", c.Args)
+	setDuration(&body, "interval", c.Interval)
+	setDuration(&body, "timeout", c.Timeout)
+	return hcler.Block{Body: body}
+}
+
+// Network is a group `network` block.
+type Network struct {
+	Mode  string // host, bridge, ...
+	Ports []Port
+}
+
+// Port is a network `port "label"` block.
+type Port struct {
+	Label       string
+	Static      int
+	To          int
+	HostNetwork string
+}
+
+func (n Network) block() hcler.Block {
+	var body hcler.OrderedMap
+	setString(&body, "mode", n.Mode)
+	if len(n.Ports) > 0 {
+		ports := make(hcler.Blocks, 0, len(n.Ports))
+		for _, p := range n.Ports {
+			var port hcler.OrderedMap
+			setInt(&port, "static", p.Static)
+			setInt(&port, "to", p.To)
+			setString(&port, "host_network", p.HostNetwork)
+			ports = append(ports, hcler.Block{Labels: []string{p.Label}, Body: port})
+		}
+		body.Set("port", ports)
+	}
+	return hcler.Block{Body: body}
+}
+
+// Template is a task `template` block.
+// Data is rendered as a heredoc, Nomad's own `${...}` interpolation
+// is escaped so the Go template is left as is.
+type Template struct {
+	Data         string
+	Source       string
+	Destination  string
+	ChangeMode   string // noop, restart, signal or script.
+	ChangeSignal string
+	Env          bool
+}
+
+func (t Template) block() hcler.Block {
+	var body hcler.OrderedMap
+	if t.Data != "" {
+		body.Set("data", hcler.NewTemplate(t.Data).Heredoc("EOH"))
+	}
+	setString(&body, "source", t.Source)
+	setString(&body, "destination", t.Destination)
+	setString(&body, "change_mode", t.ChangeMode)
+	setString(&body, "change_signal", t.ChangeSignal)
+	if t.Env {
+		body.Set("env", expr.Bool(true))
+	}
+	return hcler.Block{Body: body}
+}
+
+// Resources is a task `resources` block.
+type Resources struct {
+	CPU         int // MHz.
+	Cores       int
+	MemoryMB    int
+	MemoryMaxMB int
+}
+
+func (r Resources) block() hcler.Block {
+	var body hcler.OrderedMap
+	setInt(&body, "cpu", r.CPU)
+	setInt(&body, "cores", r.Cores)
+	setInt(&body, "memory", r.MemoryMB)
+	setInt(&body, "memory_max", r.MemoryMaxMB)
+	return hcler.Block{Body: body}
+}
+
+// Constraint is a `constraint` block, i.e.
+// Constraint{Attribute: "${attr.kernel.name}", Value: "linux"}.
+type Constraint struct {
+	Attribute string
+	Operator  string // Defaults to "=".
+	Value     string
+}
+
+func (c Constraint) block() hcler.Block {
+	var body hcler.OrderedMap
+	setString(&body, "attribute", c.Attribute)
+	setString(&body, "operator", c.Operator)
+	setString(&body, "value", c.Value)
+	return hcler.Block{Body: body}
+}
+
+func setConstraints(body *hcler.OrderedMap, cs []Constraint) {
+	if len(cs) == 0 {
+		return
+	}
+	blocks := make(hcler.Blocks, 0, len(cs))
+	for _, c := range cs {
+		blocks = append(blocks, c.block())
+	}
+	body.Set("constraint", blocks)
+}
+
+func setServices(body *hcler.OrderedMap, services []Service) error {
+	if len(services) == 0 {
+		return nil
+	}
+	blocks := make(hcler.Blocks, 0, len(services))
+	for _, s := range services {
+		b, err := s.block()
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, b)
+	}
+	body.Set("service", blocks)
+	return nil
+}
+
+func setMeta(body *hcler.OrderedMap, meta map[string]string) {
+	if len(meta) > 0 {
+		body.Set("meta", hcler.Block{Body: stringMap(meta)})
+	}
+}
+
+func setString(body *hcler.OrderedMap, k, v string) {
+	if v != "" {
+		body.Set(k, v)
+	}
+}
+
+func setInt(body *hcler.OrderedMap, k string, v int) {
+	if v != 0 {
+		body.Set(k, v)
+	}
+}
+
+func setDuration(body *hcler.OrderedMap, k string, d time.Duration) {
+	if d != 0 {
+		body.Set(k, d.String())
+	}
+}
+
+func setStrings(body *hcler.OrderedMap, k string, l []string) {
+	if len(l) == 0 {
+		return
+	}
+	out := make(hcler.List, 0, len(l))
+	for _, s := range l {
+		out = append(out, s)
+	}
+	body.Set(k, out)
+}
+
+// stringMap converts the given map to a body with sorted keys.
+func stringMap(m map[string]string) hcler.OrderedMap {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make(hcler.OrderedMap, 0, len(m))
+	for _, k := range keys {
+		out = append(out, hcler.KeyValue{Key: k, Value: m[k]})
+	}
+	return out
+}
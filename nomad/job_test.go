@@ -0,0 +1,159 @@
+package nomad_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/nomad"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob(t *testing.T) {
+	job := nomad.Job{
+		Name:        "web",
+		Type:        "service",
+		Datacenters: []string{"dc1"},
+		Constraints: []nomad.Constraint{{Attribute: "${attr.kernel.name}", Value: "linux"}},
+		Groups: []nomad.Group{{
+			Name:  "frontend",
+			Count: 2,
+			Networks: []nomad.Network{{
+				Mode:  "bridge",
+				Ports: []nomad.Port{{Label: "http", To: 8080}},
+			}},
+			Services: []nomad.Service{{
+				Name: "web",
+				Port: "http",
+				Tags: []string{"public"},
+				Checks: []nomad.Check{{
+					Type:     "http",
+					Path:     "/health",
+					Interval: 10 * time.Second,
+					Timeout:  2 * time.Second,
+				}},
+			}},
+			Tasks: []nomad.Task{
+				{
+					Name:   "app",
+					Driver: "docker",
+					Config: hcler.OrderedMap{
+						{Key: "image", Value: "nginx:1.25"},
+						{Key: "ports", Value: hcler.List{"http"}},
+					},
+					Env: map[string]string{"PORT": "8080", "MODE": "prod"},
+					Templates: []nomad.Template{{
+						Data:        "PORT={{ env \"NOMAD_PORT_http\" }}\nHOME=${HOME}\n",
+						Destination: "local/app.env",
+						Env:         true,
+					}},
+					Resources: &nomad.Resources{CPU: 500, MemoryMB: 256},
+				},
+				{Name: "sidecar", Driver: "exec", Config: hcler.Map{"command": "/bin/sidecar"}},
+			},
+		}},
+	}
+	out, err := job.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, `job "web" {
+  type        = "service"
+  datacenters = ["dc1"]
+  constraint {
+    attribute = "${attr.kernel.name}"
+    value     = "linux"
+  }
+  group "frontend" {
+    count = 2
+    network {
+      mode = "bridge"
+      port "http" {
+        to = 8080
+      }
+    }
+    service {
+      name = "web"
+      port = "http"
+      tags = ["public"]
+      check {
+        type     = "http"
+        path     = "/health"
+        interval = "10s"
+        timeout  = "2s"
+      }
+    }
+    task "app" {
+      driver = "docker"
+      config {
+        image = "nginx:1.25"
+        ports = ["http"]
+      }
+      env {
+        MODE = "prod"
+        PORT = "8080"
+      }
+      template {
+        data        = <<EOH
+PORT={{ env "NOMAD_PORT_http" }}
+HOME=$${HOME}
+EOH
+        destination = "local/app.env"
+        env         = true
+      }
+      resources {
+        cpu    = 500
+        memory = 256
+      }
+    }
+    task "sidecar" {
+      driver = "exec"
+      config {
+        command = "/bin/sidecar"
+      }
+    }
+  }
+}
+`, out)
+
+	_, diags := hclsyntax.ParseConfig([]byte(out), "web.nomad", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+}
+
+func TestJobErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		job  nomad.Job
+		err  string
+	}{
+		{name: "no name", job: nomad.Job{}, err: "job name is required"},
+		{name: "no group", job: nomad.Job{Name: "a"}, err: `job "a": at least one group is required`},
+		{
+			name: "no task",
+			job:  nomad.Job{Name: "a", Groups: []nomad.Group{{Name: "g"}}},
+			err:  `job "a": group "g": at least one task is required`,
+		},
+		{
+			name: "no driver",
+			job:  nomad.Job{Name: "a", Groups: []nomad.Group{{Name: "g", Tasks: []nomad.Task{{Name: "t"}}}}},
+			err:  `job "a": group "g": task "t": driver is required`,
+		},
+		{
+			name: "no check type",
+			job: nomad.Job{Name: "a", Groups: []nomad.Group{{
+				Name:     "g",
+				Services: []nomad.Service{{Name: "s", Checks: []nomad.Check{{Path: "/"}}}},
+				Tasks:    []nomad.Task{{Name: "t", Driver: "exec"}},
+			}}},
+			err: `job "a": group "g": service "s": check type is required`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.job.Encode()
+			require.Error(t, err)
+			assert.Equal(t, tc.err, err.Error())
+		})
+	}
+}
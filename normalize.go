@@ -0,0 +1,139 @@
+package hcler
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Normalize converts the given value to a tree of Map and List:
+// nested IMap, map[interface{}]interface{} and map[string]interface{}
+// become Map, []interface{} become List. OrderedMap keep their order.
+// Other values are left as is.
+//
+// The result can be inspected and encoded without further conversion,
+// which is useful for YAML sourced data.
+func Normalize(v interface{}, opts ...Option) (interface{}, error) {
+	e := newEncodeState(opts)
+	defer e.release()
+
+	return e.normalize(v)
+}
+
+// DeepMap is the recursive version of Map: nested maps and lists
+// are normalized as well. See Normalize.
+// Fails with ErrKeyCollision if distinct keys convert to the same string,
+// Normalize with the KeyCollisions option allows to resolve them.
+func (m IMap) DeepMap() (Map, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	e := newEncodeState(nil)
+	defer e.release()
+
+	return e.normalizeIMap(m)
+}
+
+func (e *encodeState) normalize(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case Map:
+		return e.normalizeMap(v)
+	case map[string]interface{}:
+		return e.normalizeMap(v)
+	case IMap:
+		return e.normalizeIMap(v)
+	case map[interface{}]interface{}:
+		return e.normalizeIMap(v)
+	case OrderedMap:
+		return e.normalizeOrderedMap(v)
+	case List:
+		return e.normalizeList(v)
+	case []interface{}:
+		return e.normalizeList(v)
+	default:
+		return v, nil
+	}
+}
+
+func (e *encodeState) normalizeMap(m Map) (Map, error) {
+	if err := e.enter(m, 0); err != nil {
+		return nil, err
+	}
+	defer e.leave()
+
+	out := make(Map, len(m))
+	for k, v := range m {
+		if err := e.normalizeEntry(out, k, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (e *encodeState) normalizeIMap(m IMap) (Map, error) {
+	if err := e.enter(m, 0); err != nil {
+		return nil, err
+	}
+	defer e.leave()
+
+	out, err := stringifyKeys(m, e.collisions)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert to hcl.Map")
+	}
+	for k, v := range out {
+		if err := e.normalizeEntry(out, k, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (e *encodeState) normalizeOrderedMap(m OrderedMap) (OrderedMap, error) {
+	if err := e.enterOrdered(m); err != nil {
+		return nil, err
+	}
+	defer e.leave()
+
+	out := make(OrderedMap, 0, len(m))
+	for _, kv := range m {
+		e.pushKey(kv.Key)
+		v, err := e.normalize(kv.Value)
+		e.popPath()
+		if err != nil {
+			return nil, errors.Wrapf(err, "normalize map value for key %q", kv.Key)
+		}
+		out = append(out, KeyValue{Key: kv.Key, Value: v})
+	}
+	return out, nil
+}
+
+func (e *encodeState) normalizeEntry(out Map, k string, v interface{}) error {
+	e.pushKey(k)
+	v, err := e.normalize(v)
+	e.popPath()
+	if err != nil {
+		return errors.Wrapf(err, "normalize map value for key %q", k)
+	}
+	out[k] = v
+	return nil
+}
+
+func (e *encodeState) normalizeList(l List) (List, error) {
+	if len(l) == 0 {
+		return List{}, nil
+	}
+	if err := e.enterList(l); err != nil {
+		return nil, err
+	}
+	defer e.leave()
+
+	out := make(List, len(l))
+	for i, v := range l {
+		e.pushIndex(i)
+		v, err := e.normalize(v)
+		e.popPath()
+		if err != nil {
+			return nil, errors.Wrap(err, "normalize list element")
+		}
+		out[i] = v
+	}
+	return out, nil
+}
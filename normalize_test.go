@@ -0,0 +1,65 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	in := hcler.IMap{
+		"name": "web",
+		1:      map[interface{}]interface{}{"a": []interface{}{map[interface{}]interface{}{true: "yes"}, "x"}},
+		"tags": map[string]interface{}{"env": hcler.IMap{3: nil}},
+		"ids":  []int{1, 2},
+		"none": []interface{}{},
+	}
+	expect := hcler.Map{
+		"name": "web",
+		"1":    hcler.Map{"a": hcler.List{hcler.Map{"1": "yes"}, "x"}},
+		"tags": hcler.Map{"env": hcler.Map{"3": nil}},
+		"ids":  []int{1, 2},
+		"none": hcler.List{},
+	}
+
+	got, err := in.DeepMap()
+	require.NoError(t, err)
+	assert.Equal(t, expect, got)
+
+	v, err := hcler.Normalize(map[interface{}]interface{}(in))
+	require.NoError(t, err)
+	assert.Equal(t, expect, v)
+
+	v, err = hcler.Normalize([]interface{}{in, "a"})
+	require.NoError(t, err)
+	assert.Equal(t, hcler.List{expect, "a"}, v)
+
+	v, err = hcler.Normalize("scalar")
+	require.NoError(t, err)
+	assert.Equal(t, "scalar", v)
+
+	// The input is left untouched.
+	assert.IsType(t, map[interface{}]interface{}{}, in[1])
+
+	got, err = hcler.IMap{}.DeepMap()
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestNormalizeError(t *testing.T) {
+	m := hcler.IMap{}
+	m["foo"] = []interface{}{m}
+	_, err := m.DeepMap()
+	require.Error(t, err)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+	assert.Contains(t, err.Error(), "foo[0]: encoding cycle")
+
+	_, err = hcler.Normalize(hcler.Map{"a": hcler.Map{"b": hcler.Map{}}}, hcler.MaxDepth(2))
+	assert.Equal(t, hcler.ErrMaxDepth, errors.Cause(err))
+
+	_, err = hcler.Normalize(hcler.IMap{struct{}{}: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package hcler
+
+import "github.com/zclconf/go-cty/cty"
+
+// Null encodes as the HCL null literal, i.e. to set an optional
+// argument back to its default.
+var Null Encoder = nullValue{}
+
+type nullValue struct{}
+
+func (nullValue) EncodeHCL() (string, error) { return "null", nil }
+
+// NilAsNull encodes untyped nil and nil pointers as null instead of "".
+func NilAsNull() Option {
+	return func(e *encodeState) { e.nilAsNull = true }
+}
+
+// isNull reports whether v encodes as null: nil, Null or a null cty.Value.
+func isNull(v interface{}) bool {
+	switch v := v.(type) {
+	case nil, nullValue:
+		return true
+	case cty.Value:
+		return v.IsKnown() && v.IsNull()
+	}
+	return false
+}
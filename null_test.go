@@ -0,0 +1,54 @@
+package hcler_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestNull(t *testing.T) {
+	out, err := hcler.Encode(hcler.Map{"a": hcler.Null, "b": hcler.List{1, hcler.Null}, "c": nil}, hcler.SortKeys())
+	require.NoError(t, err)
+	assert.Equal(t, `{ a = null, b = [ 1, null ], c = "" }`, out)
+
+	out, err = hcler.EncodeBody(hcler.OrderedMap{
+		{Key: "ami", Value: hcler.Null},
+		{Key: "tags", Value: hcler.Map{"name": hcler.Null}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ami = null\ntags = {\n  name = null\n}\n", out)
+
+	v, err := hcler.ToCty(hcler.Null)
+	require.NoError(t, err)
+	assert.True(t, v.IsNull())
+
+	var buf bytes.Buffer
+	require.NoError(t, hcler.WriteTFVars(&buf, map[string]interface{}{"ami": hcler.Null}))
+	assert.Equal(t, "ami = null\n", buf.String())
+}
+
+func TestNilAsNull(t *testing.T) {
+	type resource struct {
+		Name  string  `hcl:"name"`
+		Count *int    `hcl:"count"`
+		Zone  *string `hcl:"zone,omitempty"`
+	}
+	out, err := hcler.Encode(hcler.List{nil, resource{Name: "web"}}, hcler.NilAsNull())
+	require.NoError(t, err)
+	assert.Equal(t, `[ null, { name = "web", count = null } ]`, out)
+
+	out, err = hcler.EncodeBody(hcler.Map{"a": nil}, hcler.NilAsNull())
+	require.NoError(t, err)
+	assert.Equal(t, "a = null\n", out)
+
+	// Round trip of cty nulls.
+	v, err := hcler.FromCty(cty.ObjectVal(map[string]cty.Value{"a": cty.NullVal(cty.String)}))
+	require.NoError(t, err)
+	out, err = hcler.Encode(v, hcler.NilAsNull())
+	require.NoError(t, err)
+	assert.Equal(t, `{ a = null }`, out)
+}
@@ -0,0 +1,94 @@
+package hcler
+
+import "sync"
+
+// Option configures the encoding.
+type Option func(*encodeState)
+
+// StrictKeys makes the encoding fail with ErrInvalidKey when a key
+// is not a valid identifier and can't be used as an attribute name.
+func StrictKeys() Option {
+	return func(e *encodeState) { e.strictKeys = true }
+}
+
+// QuoteKeys forces quoting of all keys, as allowed in object literals.
+func QuoteKeys() Option {
+	return func(e *encodeState) { e.quoteKeys = true }
+}
+
+// JSONFallback makes the encoding of otherwise unsupported types
+// implementing json.Marshaler go through their JSON representation.
+func JSONFallback() Option {
+	return func(e *encodeState) { e.jsonFallback = true }
+}
+
+// MaxDepth makes the encoding fail with ErrMaxDepth when maps and lists
+// are nested deeper than n. 0 means no limit.
+func MaxDepth(n int) Option {
+	return func(e *encodeState) { e.maxDepth = n }
+}
+
+// SortKeys sorts map keys, for a deterministic output.
+func SortKeys() Option {
+	return func(e *encodeState) { e.sortKeys = true }
+}
+
+// Indent pretty prints objects and lists over multiple lines,
+// indented with the given string.
+func Indent(indent string) Option {
+	return func(e *encodeState) { e.indent = indent }
+}
+
+// KeyCollisions sets the policy applied when distinct IMap keys
+// convert to the same string. Defaults to CollisionError.
+func KeyCollisions(policy KeyCollisionPolicy) Option {
+	return func(e *encodeState) { e.collisions = policy }
+}
+
+// encodeState holds the encoding options and the state
+// used to detect cycles.
+type encodeState struct {
+	strictKeys   bool
+	quoteKeys    bool
+	jsonFallback bool
+	sortKeys     bool
+	indent       string
+	maxDepth     int
+	collisions   KeyCollisionPolicy
+	canonical    bool // Encode equal values the same, see Equal.
+	nilAsNull    bool
+
+	unknownEncoders bool // Convert Encoder values to unknown cty values, see Validate.
+
+	level int // Nesting level for indentation.
+
+	buf   []byte     // Encoded output.
+	stack []visit    // Maps and lists being encoded.
+	path  []pathElem // Current position in the tree.
+}
+
+// encodeStatePool recycles the encoding buffers.
+var encodeStatePool sync.Pool
+
+// maxPooledBuffer is the capacity above which buffers are not recycled
+// to avoid holding on large memory after encoding a big document.
+const maxPooledBuffer = 64 << 10
+
+func newEncodeState(opts []Option) *encodeState {
+	e, _ := encodeStatePool.Get().(*encodeState)
+	if e == nil {
+		e = &encodeState{}
+	}
+	*e = encodeState{buf: e.buf[:0], stack: e.stack[:0], path: e.path[:0]}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *encodeState) release() {
+	if cap(e.buf) > maxPooledBuffer {
+		return
+	}
+	encodeStatePool.Put(e)
+}
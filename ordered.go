@@ -0,0 +1,178 @@
+package hcler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// KeyValue is an OrderedMap entry.
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedMap is a map preserving the order of its keys, i.e. to emit
+// `source` before `version` in a Terraform module.
+// Keys are expected to be unique, which Set maintains.
+// The order is kept as is, even with the SortKeys option, but is
+// ignored by Equal and Hash.
+type OrderedMap []KeyValue
+
+// Get returns the value of the given key.
+func (m OrderedMap) Get(k string) (interface{}, bool) {
+	if i := m.index(k); i >= 0 {
+		return m[i].Value, true
+	}
+	return nil, false
+}
+
+// Set sets the value of the given key. New keys are appended,
+// existing ones keep their position.
+func (m *OrderedMap) Set(k string, v interface{}) {
+	if i := m.index(k); i >= 0 {
+		(*m)[i].Value = v
+		return
+	}
+	*m = append(*m, KeyValue{Key: k, Value: v})
+}
+
+// Delete removes the given key.
+func (m *OrderedMap) Delete(k string) {
+	if i := m.index(k); i >= 0 {
+		*m = append((*m)[:i], (*m)[i+1:]...)
+	}
+}
+
+// Keys returns the keys in order.
+func (m OrderedMap) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for _, kv := range m {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+// Map converts an hcl.OrderedMap to a hcl.Map, losing the order.
+func (m OrderedMap) Map() Map {
+	if m == nil {
+		return nil
+	}
+	out := make(Map, len(m))
+	for _, kv := range m {
+		out[kv.Key] = kv.Value
+	}
+	return out
+}
+
+func (m OrderedMap) index(k string) int {
+	for i, kv := range m {
+		if kv.Key == k {
+			return i
+		}
+	}
+	return -1
+}
+
+// EncodeHCL implements the hcl.Encoder interface.
+func (m OrderedMap) EncodeHCL() (string, error) {
+	return encodeString(m, nil)
+}
+
+func (e *encodeState) encodeOrderedMap(m OrderedMap) error {
+	if len(m) == 0 {
+		e.buf = append(e.buf, "{}"...)
+		return nil
+	}
+	if err := e.enterOrdered(m); err != nil {
+		return err
+	}
+	defer e.leave()
+
+	start := e.openObject()
+	if e.canonical {
+		if err := e.writeEntries(m.Map()); err != nil {
+			return err
+		}
+		e.closeObject(start)
+		return nil
+	}
+	for _, kv := range m {
+		if err := e.writeEntry(kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+	e.closeObject(start)
+	return nil
+}
+
+// DecodeJSON decodes the given JSON document to Map, List and scalars,
+// with numbers as json.Number.
+// When preserveOrder is set, objects are decoded as OrderedMap instead of Map.
+func DecodeJSON(data []byte, preserveOrder bool) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeJSONValue(dec, preserveOrder)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode json")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("decode json: unexpected data after the top-level value")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder, preserveOrder bool) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		var om OrderedMap
+		m := Map{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k, _ := tok.(string)
+			v, err := decodeJSONValue(dec, preserveOrder)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode %q", k)
+			}
+			if preserveOrder {
+				om.Set(k, v)
+			} else {
+				m[k] = v
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		if preserveOrder {
+			if om == nil {
+				om = OrderedMap{}
+			}
+			return om, nil
+		}
+		return m, nil
+	case json.Delim('['):
+		l := List{}
+		for dec.More() {
+			v, err := decodeJSONValue(dec, preserveOrder)
+			if err != nil {
+				return nil, errors.Wrap(err, "decode list element")
+			}
+			l = append(l, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return l, nil
+	default:
+		return tok, nil
+	}
+}
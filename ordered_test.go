@@ -0,0 +1,104 @@
+package hcler_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var _ hcler.Encoder = hcler.OrderedMap(nil)
+
+func TestOrderedMap(t *testing.T) {
+	var m hcler.OrderedMap
+	m.Set("source", "./module")
+	m.Set("version", "1.0.0")
+	m.Set("providers", hcler.OrderedMap{{Key: "aws", Value: "aws.west"}})
+	m.Set("count", 1)
+	m.Set("version", "1.1.0")
+	m.Delete("count")
+	m.Delete("missing")
+
+	assert.Equal(t, []string{"source", "version", "providers"}, m.Keys())
+	v, ok := m.Get("version")
+	assert.True(t, ok)
+	assert.Equal(t, "1.1.0", v)
+	_, ok = m.Get("count")
+	assert.False(t, ok)
+
+	assert.Equal(t, hcler.Map{"source": "./module", "version": "1.1.0", "providers": hcler.OrderedMap{{Key: "aws", Value: "aws.west"}}}, m.Map())
+	assert.Nil(t, hcler.OrderedMap(nil).Map())
+
+	expect := `{ source = "./module", version = "1.1.0", providers = { aws = "aws.west" } }`
+	assertEncoding([]string{expect}, m)(t)
+
+	got, err := hcler.Encode(m, hcler.SortKeys())
+	require.NoError(t, err)
+	assert.Equal(t, expect, got)
+
+	got, err = hcler.Encode(hcler.OrderedMap{})
+	require.NoError(t, err)
+	assert.Equal(t, "{}", got)
+}
+
+func TestOrderedMapSupport(t *testing.T) {
+	m := hcler.OrderedMap{
+		{Key: "source", Value: "./module"},
+		{Key: "version", Value: "1.0.0"},
+		{Key: "tags", Value: hcler.OrderedMap{{Key: "b", Value: 1}, {Key: "a", Value: map[interface{}]interface{}{"c": 2}}}},
+	}
+
+	body, err := hcler.EncodeBody(m)
+	require.NoError(t, err)
+	assert.Equal(t, "source  = \"./module\"\nversion = \"1.0.0\"\ntags = {\n  b = 1\n  a = {\n    c = 2\n  }\n}\n", body)
+
+	v, err := hcler.Normalize(m)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.OrderedMap{
+		{Key: "source", Value: "./module"},
+		{Key: "version", Value: "1.0.0"},
+		{Key: "tags", Value: hcler.OrderedMap{{Key: "b", Value: 1}, {Key: "a", Value: hcler.Map{"c": 2}}}},
+	}, v)
+
+	val, err := hcler.ToCty(m)
+	require.NoError(t, err)
+	assert.Equal(t, cty.StringVal("1.0.0"), val.GetAttr("version"))
+
+	cyclic := hcler.OrderedMap{{Key: "self"}}
+	cyclic[0].Value = cyclic
+	err = assertEncodeCause(t, hcler.ErrCycle, cyclic)
+	assert.Contains(t, err.Error(), "self: encoding cycle")
+	_, err = hcler.ToCty(cyclic)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+	_, err = hcler.Normalize(cyclic)
+	assert.Equal(t, hcler.ErrCycle, errors.Cause(err))
+}
+
+func TestDecodeJSON(t *testing.T) {
+	data := []byte(`{"source": "./module", "version": 1.5, "providers": {"b": [true, null], "a": {}}, "source": "./other"}`)
+
+	v, err := hcler.DecodeJSON(data, true)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.OrderedMap{
+		{Key: "source", Value: "./other"},
+		{Key: "version", Value: json.Number("1.5")},
+		{Key: "providers", Value: hcler.OrderedMap{{Key: "b", Value: hcler.List{true, nil}}, {Key: "a", Value: hcler.OrderedMap{}}}},
+	}, v)
+
+	v, err = hcler.DecodeJSON(data, false)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.Map{
+		"source":    "./other",
+		"version":   json.Number("1.5"),
+		"providers": hcler.Map{"b": hcler.List{true, nil}, "a": hcler.Map{}},
+	}, v)
+
+	for _, data := range []string{`{"a": }`, `[1, 2`, `{} {}`, ``} {
+		_, err := hcler.DecodeJSON([]byte(data), true)
+		assert.Error(t, err, data)
+	}
+}
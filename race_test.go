@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package hcler_test
+
+// raceEnabled is set when testing with the race detector, under which
+// sync.Pool randomly drops items and allocation counts are meaningless.
+const raceEnabled = true
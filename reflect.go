@@ -0,0 +1,400 @@
+package hcler
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// UnsupportedTypeError is returned when encoding a value of a type
+// which can't be represented in HCL.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "unsupported type " + e.Type.String()
+}
+
+// encoderFunc encodes a reflected value.
+type encoderFunc func(e *encodeState, v reflect.Value) error
+
+// encoderCache holds the compiled encoder of each type.
+var encoderCache sync.Map // map[reflect.Type]encoderFunc
+
+// fieldCache holds the fields of each struct type.
+var fieldCache sync.Map // map[reflect.Type][]field
+
+var (
+	encoderType       = reflect.TypeOf((*Encoder)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+
+	// Types handled by encode/toString directly.
+	knownTypes = map[reflect.Type]struct{}{
+		reflect.TypeOf(Map(nil)):                         {},
+		reflect.TypeOf(IMap(nil)):                        {},
+		reflect.TypeOf(List(nil)):                        {},
+		reflect.TypeOf(map[string]interface{}(nil)):      {},
+		reflect.TypeOf(map[interface{}]interface{}(nil)): {},
+		reflect.TypeOf([]interface{}(nil)):               {},
+		reflect.TypeOf([]byte(nil)):                      {},
+		reflect.TypeOf(Runes(nil)):                       {},
+		reflect.TypeOf((*big.Int)(nil)):                  {},
+		reflect.TypeOf((*big.Float)(nil)):                {},
+		reflect.TypeOf(json.Number("")):                  {},
+	}
+)
+
+// encodeReflect encodes the given value using its type's encoder.
+func (e *encodeState) encodeReflect(v reflect.Value) error {
+	return typeEncoder(v.Type())(e, v)
+}
+
+// typeEncoder returns the cached encoder for the given type,
+// compiling it if needed.
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// Recursive types refer to their own encoder while it is being compiled,
+	// store an indirect func waiting for the real one.
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := encoderCache.LoadOrStore(t, encoderFunc(func(e *encodeState, v reflect.Value) error {
+		wg.Wait()
+		return f(e, v)
+	}))
+	if loaded {
+		return fi.(encoderFunc)
+	}
+
+	f = newTypeEncoder(t)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+// newTypeEncoder compiles the encoder of the given type.
+// nolint: gocyclo
+func newTypeEncoder(t reflect.Type) encoderFunc {
+	var f encoderFunc
+	if _, ok := knownTypes[t]; ok || t.Implements(encoderType) || t.Implements(textMarshalerType) ||
+		t.Implements(stringerType) || t.Implements(errorType) {
+		f = interfaceEncoder
+	} else {
+		switch t.Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+			f = scalarEncoder
+		case reflect.Interface:
+			f = interfaceEncoder
+		case reflect.Ptr:
+			f = newPtrEncoder(t)
+		case reflect.Struct:
+			f = newStructEncoder(t)
+		case reflect.Map:
+			f = newMapEncoder(t)
+		case reflect.Slice, reflect.Array:
+			f = newSliceEncoder(t)
+		default:
+			f = unsupportedEncoder
+		}
+		// As in encode, JSON is only a fallback for types hcler doesn't know.
+		if t.Implements(jsonMarshalerType) {
+			f = jsonFallbackEncoder(f)
+		}
+	}
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		f = nilEncoder(f)
+	}
+	return f
+}
+
+func unsupportedEncoder(_ *encodeState, v reflect.Value) error {
+	return &UnsupportedTypeError{Type: v.Type()}
+}
+
+// interfaceEncoder defers to encode for types it knows.
+func interfaceEncoder(e *encodeState, v reflect.Value) error {
+	return e.encode(v.Interface())
+}
+
+// nilEncoder encodes nil pointers and interfaces as nil.
+func nilEncoder(f encoderFunc) encoderFunc {
+	return func(e *encodeState, v reflect.Value) error {
+		if v.IsNil() {
+			return e.encode(nil)
+		}
+		return f(e, v)
+	}
+}
+
+// jsonFallbackEncoder uses the json.Marshaler implementation
+// when the JSONFallback option is set.
+func jsonFallbackEncoder(f encoderFunc) encoderFunc {
+	return func(e *encodeState, v reflect.Value) error {
+		if e.jsonFallback {
+			return e.encodeJSON(v.Interface().(json.Marshaler))
+		}
+		return f(e, v)
+	}
+}
+
+// scalarValue converts named booleans, numbers and strings to their base type.
+func scalarValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32:
+		return float32(v.Float())
+	case reflect.Float64:
+		return v.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex()
+	default:
+		return nil
+	}
+}
+
+func scalarEncoder(e *encodeState, v reflect.Value) error {
+	buf, err := appendString(e.buf, scalarValue(v), true)
+	if err != nil {
+		return err
+	}
+	e.buf = buf
+	return nil
+}
+
+func newPtrEncoder(t reflect.Type) encoderFunc {
+	elemEnc := typeEncoder(t.Elem())
+	return func(e *encodeState, v reflect.Value) error {
+		if err := e.enterPtr(v.Pointer(), 0, t.Elem()); err != nil {
+			return err
+		}
+		defer e.leave()
+		return elemEnc(e, v.Elem())
+	}
+}
+
+// field is a compiled struct field.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	enc       encoderFunc
+}
+
+// typeFields lists the encoded fields of the given struct type.
+// Fields are named after their `hcl:"name,omitempty"` tag, or their Go name.
+// Fields tagged with `hcl:"-"` and unexported fields are ignored.
+// Embedded structs without tag are inlined.
+func typeFields(t reflect.Type, index []int) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" { // Unexported.
+			continue
+		}
+		tag := sf.Tag.Get("hcl")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, typeFields(sf.Type, fieldIndex)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: opts == "omitempty",
+			enc:       typeEncoder(sf.Type),
+		})
+	}
+	return fields
+}
+
+// cachedTypeFields is typeFields using the cache.
+func cachedTypeFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return f.([]field)
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	fields := cachedTypeFields(t)
+	return func(e *encodeState, v reflect.Value) error {
+		start := e.openObject()
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			if err := e.beginEntry(f.name); err != nil {
+				return err
+			}
+			e.pushKey(f.name)
+			err := f.enc(e, fv)
+			e.popPath()
+			if err != nil {
+				return errors.Wrapf(err, "encode %q", f.name)
+			}
+			e.endEntry()
+		}
+		e.closeObject(start)
+		return nil
+	}
+}
+
+func newMapEncoder(t reflect.Type) encoderFunc {
+	elemEnc := typeEncoder(t.Elem())
+	return func(e *encodeState, v reflect.Value) error {
+		if v.Len() == 0 {
+			e.buf = append(e.buf, "{}"...)
+			return nil
+		}
+		if err := e.enterPtr(v.Pointer(), 0, t); err != nil {
+			return err
+		}
+		defer e.leave()
+
+		keys, values, err := mapEntries(v, e.collisions)
+		if err != nil {
+			return err
+		}
+		if e.sortKeys {
+			sort.Sort(byKey{keys: keys, values: values})
+		}
+
+		start := e.openObject()
+		for i, k := range keys {
+			if err := e.beginEntry(k); err != nil {
+				return err
+			}
+			e.pushKey(k)
+			err := elemEnc(e, values[i])
+			e.popPath()
+			if err != nil {
+				return errors.Wrapf(err, "encode %q", k)
+			}
+			e.endEntry()
+		}
+		e.closeObject(start)
+		return nil
+	}
+}
+
+// byKey sorts map entries by key.
+type byKey struct {
+	keys   []string
+	values []reflect.Value
+}
+
+func (b byKey) Len() int           { return len(b.keys) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+}
+
+// mapKey stringifies the given map key.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+	if !k.IsValid() {
+		return toString(nil, false)
+	}
+	s, err := toString(k.Interface(), false)
+	if _, ok := err.(*UnsupportedTypeError); ok && scalarValue(k) != nil {
+		// Named strings, booleans and numbers.
+		return toString(scalarValue(k), false)
+	}
+	return s, err
+}
+
+func newSliceEncoder(t reflect.Type) encoderFunc {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		// Named byte slices are strings, like []byte.
+		return func(e *encodeState, v reflect.Value) error {
+			e.buf = appendQuote(e.buf, string(v.Bytes()), true)
+			return nil
+		}
+	}
+	elemEnc := typeEncoder(t.Elem())
+	return func(e *encodeState, v reflect.Value) error {
+		if v.Len() == 0 {
+			e.buf = append(e.buf, "[]"...)
+			return nil
+		}
+		if v.Kind() == reflect.Slice {
+			if err := e.enterPtr(v.Pointer(), v.Len(), t); err != nil {
+				return err
+			}
+			defer e.leave()
+		}
+
+		e.openList()
+		for i := 0; i < v.Len(); i++ {
+			e.newline()
+			e.pushIndex(i)
+			err := elemEnc(e, v.Index(i))
+			e.popPath()
+			if err != nil {
+				return errors.Wrap(err, "encode list element")
+			}
+			e.endElem()
+		}
+		e.closeList()
+		return nil
+	}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
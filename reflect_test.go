@@ -0,0 +1,136 @@
+package hcler_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/hcler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type port uint16
+
+type level string
+
+type resources struct {
+	CPU    int  `hcl:"cpu"`
+	Memory *int `hcl:"memory,omitempty"`
+}
+
+type meta struct {
+	Owner string `hcl:"owner,omitempty"`
+}
+
+type service struct {
+	meta
+	Labels
+	Name      string            `hcl:"name"`
+	Ports     []port            `hcl:"ports"`
+	Level     level             `hcl:"level"`
+	Resources *resources        `hcl:"resources,omitempty"`
+	Env       map[string]string `hcl:"env,omitempty"`
+	Timeout   time.Duration     `hcl:"timeout"`
+	Ignored   string            `hcl:"-"`
+	private   string
+}
+
+type Labels struct {
+	Team string `hcl:"team,omitempty"`
+}
+
+type node struct {
+	Value int   `hcl:"value"`
+	Next  *node `hcl:"next,omitempty"`
+}
+
+func TestEncodeStruct(t *testing.T) {
+	mem := 256
+	s := service{
+		meta:      meta{Owner: "not encoded, unexported embedded type"},
+		Labels:    Labels{Team: "core"},
+		Name:      "web",
+		Ports:     []port{80, 443},
+		Level:     "info",
+		Resources: &resources{CPU: 500, Memory: &mem},
+		Env:       map[string]string{"FOO": "bar"},
+		Timeout:   time.Second,
+		Ignored:   "ignored",
+		private:   "private",
+	}
+	expect := `{ team = "core", name = "web", ports = [ 80, 443 ], level = "info", resources = { cpu = 500, memory = 256 }, env = { FOO = "bar" }, timeout = "1s" }`
+	assertEncoding([]string{expect}, s)(t)
+	assertEncoding([]string{expect}, &s)(t)
+	assertEncoding([]string{`{ svc = ` + expect + ` }`}, hcler.Map{"svc": s})(t)
+
+	t.Run("omitempty", func(t *testing.T) {
+		assertEncoding([]string{`{ name = "", ports = [], level = "", timeout = "0s" }`}, service{})(t)
+		assertEncoding([]string{`{ cpu = 0 }`}, resources{})(t)
+	})
+	t.Run("nil_pointer", func(t *testing.T) {
+		assertEncoding([]string{`""`}, (*service)(nil))(t)
+	})
+	t.Run("empty_struct", func(t *testing.T) {
+		assertEncoding([]string{`{}`}, struct{}{})(t)
+	})
+}
+
+func TestEncodeTypedCollections(t *testing.T) {
+	assertEncoding([]string{`[ "a", "b" ]`}, []string{"a", "b"})(t)
+	assertEncoding([]string{`[ 1, 2 ]`}, [2]int{1, 2})(t)
+	assertEncoding([]string{`[ [ 1 ], [] ]`}, [][]float64{{1}, nil})(t)
+	assertEncoding([]string{`{ a = [ 1 ] }`}, map[string][]int{"a": {1}})(t)
+	assertEncoding([]string{`{ "1" = "1" }`}, map[int]bool{1: true})(t)
+	assertEncoding([]string{`{ info = 1 }`}, map[level]int{"info": 1})(t)
+	assertEncoding([]string{`{ a = { b = "c" } }`}, map[string]hcler.Map{"a": {"b": "c"}})(t)
+	assertEncoding([]string{`{}`}, map[string]int{})(t)
+	assertEncoding([]string{`8080`}, port(8080))(t)
+	assertEncoding([]string{`"abc"`}, rawBytes("abc"))(t)
+}
+
+type rawBytes []byte
+
+func TestEncodeRecursiveType(t *testing.T) {
+	n := &node{Value: 1, Next: &node{Value: 2}}
+	assertEncoding([]string{`{ value = 1, next = { value = 2 } }`}, n)(t)
+
+	n.Next.Next = n
+	_, err := hcler.Encode(n)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "next.next: encoding cycle")
+}
+
+func TestEncodeReflectError(t *testing.T) {
+	for name, v := range map[string]interface{}{
+		"chan":         make(chan int),
+		"func_field":   struct{ F func() }{F: func() {}},
+		"chan_in_map":  map[string]chan int{"a": nil},
+		"complex_slot": []complex64{1},
+	} {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			_, err := hcler.Encode(v)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEncodeStructConcurrent(t *testing.T) {
+	type concurrent struct {
+		A int               `hcl:"a"`
+		B []string          `hcl:"b"`
+		C map[string]string `hcl:"c"`
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := hcler.Encode(concurrent{A: 1, B: []string{"x"}, C: map[string]string{"k": "v"}})
+			assert.NoError(t, err)
+			assert.Equal(t, `{ a = 1, b = [ "x" ], c = { k = "v" } }`, got)
+		}()
+	}
+	wg.Wait()
+}
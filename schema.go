@@ -0,0 +1,299 @@
+package hcler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Schema describes the attributes and blocks allowed in a body.
+type Schema struct {
+	Attributes map[string]AttributeSchema
+	Blocks     map[string]BlockSchema
+}
+
+// AttributeSchema describes an attribute.
+type AttributeSchema struct {
+	Type     cty.Type // Values must be convertible to it, cty.DynamicPseudoType accepts any value.
+	Required bool
+	Computed bool // Only set by the provider: any non-null value is reported.
+}
+
+// BlockSchema describes a block type.
+type BlockSchema struct {
+	Labels   []string // Label names, i.e. "type" and "name" for a Terraform resource.
+	MinItems int
+	MaxItems int     // 0 for no limit.
+	Body     *Schema // nil accepts any body.
+}
+
+// Violation is a schema violation at the given path.
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError lists all the violations found by Validate.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "schema validation: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the given body against the schema and returns a
+// *ValidationError listing all the violations, if any.
+//
+// Attribute values are converted with ToCty and checked against their
+// type. Expressions and other Encoder values are unknown until
+// evaluated and match any type.
+func Validate(v interface{}, schema *Schema) error {
+	e := newEncodeState(nil)
+	defer e.release()
+	e.unknownEncoders = true
+
+	var violations []Violation
+	e.validateBody(&violations, v, schema)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (e *encodeState) violation(violations *[]Violation, format string, args ...interface{}) {
+	*violations = append(*violations, Violation{Path: e.pathString(), Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *encodeState) validateBody(violations *[]Violation, v interface{}, schema *Schema) {
+	if schema == nil {
+		return
+	}
+	v = bodyValue(v)
+	body, ok, err := objectEntries(v)
+	if err != nil {
+		e.violation(violations, "%s", err)
+		return
+	}
+	if !ok {
+		e.violation(violations, "expected a body, got %T", v)
+		return
+	}
+	if err := e.enterObject(v); err != nil {
+		e.violation(violations, "%s", err)
+		return
+	}
+	defer e.leave()
+
+	for _, kv := range body {
+		e.pushKey(kv.Key)
+		if attr, ok := schema.Attributes[kv.Key]; ok {
+			e.validateAttribute(violations, kv.Value, attr)
+		} else if block, ok := schema.Blocks[kv.Key]; ok {
+			e.validateBlocks(violations, kv.Value, block)
+		} else {
+			e.violation(violations, "unsupported argument or block")
+		}
+		e.popPath()
+	}
+
+	var missing []string
+	for name, attr := range schema.Attributes {
+		if v, _ := body.Get(name); isNull(v) && attr.Required {
+			missing = append(missing, name)
+		}
+	}
+	for name, block := range schema.Blocks {
+		if _, ok := body.Get(name); !ok && block.MinItems > 0 {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		e.pushKey(name)
+		if block, ok := schema.Blocks[name]; ok {
+			e.violation(violations, "at least %d block(s) required, got 0", block.MinItems)
+		} else {
+			e.violation(violations, "missing required argument")
+		}
+		e.popPath()
+	}
+}
+
+func (e *encodeState) validateAttribute(violations *[]Violation, v interface{}, schema AttributeSchema) {
+	if isBlock(v) {
+		e.violation(violations, "expected an argument, got a block")
+		return
+	}
+	if isNull(v) {
+		// Checked with the required arguments.
+		return
+	}
+	if schema.Computed {
+		e.violation(violations, "unconfigurable attribute")
+		return
+	}
+	val, err := e.toCty(v)
+	if err != nil {
+		e.violation(violations, "%s", err)
+		return
+	}
+	if schema.Type == cty.NilType || schema.Type == cty.DynamicPseudoType {
+		return
+	}
+	if _, err := convert.Convert(val, schema.Type); err != nil {
+		e.violation(violations, "invalid value: %s required", schema.Type.FriendlyName())
+		return
+	}
+	// The conversion drops unexpected object attributes, report them.
+	if path := unexpectedAttribute(val, schema.Type); path != "" {
+		e.violation(violations, "unsupported attribute %s", path)
+	}
+}
+
+// unexpectedAttribute returns the path of the first attribute of the given
+// value not expected by the type, "" if none.
+func unexpectedAttribute(v cty.Value, t cty.Type) string {
+	if !v.IsKnown() || v.IsNull() || !v.CanIterateElements() {
+		return ""
+	}
+	for it := v.ElementIterator(); it.Next(); {
+		k, elem := it.Element()
+		var elemType cty.Type
+		switch {
+		case t.IsObjectType():
+			if !t.HasAttribute(k.AsString()) {
+				return k.AsString()
+			}
+			elemType = t.AttributeType(k.AsString())
+		case t.IsCollectionType():
+			elemType = t.ElementType()
+		default:
+			return ""
+		}
+		if path := unexpectedAttribute(elem, elemType); path != "" {
+			if t.IsObjectType() || t.IsMapType() {
+				return k.AsString() + "." + path
+			}
+			return path
+		}
+	}
+	return ""
+}
+
+func (e *encodeState) validateBlocks(violations *[]Violation, v interface{}, schema BlockSchema) {
+	blocks, ok := blockList(v)
+	if !ok {
+		e.violation(violations, "expected a block, got %T", v)
+		return
+	}
+	if len(blocks) < schema.MinItems {
+		e.violation(violations, "at least %d block(s) required, got %d", schema.MinItems, len(blocks))
+	}
+	if schema.MaxItems > 0 && len(blocks) > schema.MaxItems {
+		e.violation(violations, "at most %d block(s) allowed, got %d", schema.MaxItems, len(blocks))
+	}
+	for _, b := range blocks {
+		e.pushLabels(b.Labels)
+		if len(b.Labels) != len(schema.Labels) {
+			e.violation(violations, "expected %d label(s) (%s), got %d", len(schema.Labels), strings.Join(schema.Labels, ", "), len(b.Labels))
+		} else {
+			e.validateBody(violations, b.Body, schema.Body)
+		}
+		e.popLabels(b.Labels)
+	}
+}
+
+// ApplySchema returns a copy of the given body where the values of the
+// schema's block types are wrapped in Block and Blocks, so generic data,
+// i.e. decoded from JSON, encodes with the right syntax:
+// a Map-like value becomes a Block and a list of them Blocks. For block
+// types with labels, the bodies are keyed by label, one level per label,
+// i.e. {"web": {...}} for a single label.
+// Values already wrapped are kept as is.
+//
+// The result is then validated, see Validate.
+func ApplySchema(v interface{}, schema *Schema) (interface{}, error) {
+	out, err := applySchema(v, schema)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(out, schema); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func applySchema(v interface{}, schema *Schema) (interface{}, error) {
+	body, ok, err := objectEntries(v)
+	if err != nil || !ok || schema == nil {
+		// Reported by Validate.
+		return v, err
+	}
+	out := make(OrderedMap, 0, len(body))
+	for _, kv := range body {
+		value := kv.Value
+		if block, ok := schema.Blocks[kv.Key]; ok && !isBlock(value) {
+			blocks, err := wrapBlocks(value, nil, block)
+			if err != nil {
+				return nil, errors.Wrapf(err, "block %q", kv.Key)
+			}
+			if blocks != nil {
+				value = blocks
+			}
+		}
+		out = append(out, KeyValue{Key: kv.Key, Value: value})
+	}
+	if _, ok := v.(OrderedMap); ok {
+		return out, nil
+	}
+	return out.Map(), nil
+}
+
+// wrapBlocks wraps the given value in blocks. Returns nil if the value
+// doesn't have the expected shape, left for Validate to report.
+func wrapBlocks(v interface{}, labels []string, schema BlockSchema) (Blocks, error) {
+	if len(labels) < len(schema.Labels) {
+		entries, ok, err := objectEntries(v)
+		if err != nil || !ok {
+			return nil, err
+		}
+		var out Blocks
+		for _, kv := range entries {
+			blocks, err := wrapBlocks(kv.Value, append(labels[:len(labels):len(labels)], kv.Key), schema)
+			if err != nil || blocks == nil {
+				return nil, err
+			}
+			out = append(out, blocks...)
+		}
+		return out, nil
+	}
+	bodies, ok := listValue(v)
+	if !ok {
+		bodies = List{v}
+	}
+	out := make(Blocks, 0, len(bodies))
+	for _, body := range bodies {
+		if _, ok, err := objectEntries(body); err != nil || !ok {
+			return nil, err
+		}
+		body, err := applySchema(body, schema.Body)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Block{Labels: labels, Body: body})
+	}
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var testSchema = &hcler.Schema{
+	Attributes: map[string]hcler.AttributeSchema{
+		"region": {Type: cty.String, Required: true},
+	},
+	Blocks: map[string]hcler.BlockSchema{
+		"job": {
+			Labels:   []string{"name"},
+			MinItems: 1,
+			MaxItems: 1,
+			Body: &hcler.Schema{
+				Attributes: map[string]hcler.AttributeSchema{
+					"datacenters": {Type: cty.List(cty.String), Required: true},
+					"priority":    {Type: cty.Number},
+					"meta":        {Type: cty.Map(cty.String)},
+					"any":         {Type: cty.DynamicPseudoType},
+					"service":     {Type: cty.Object(map[string]cty.Type{"port": cty.Number})},
+				},
+				Blocks: map[string]hcler.BlockSchema{
+					"group": {Labels: []string{"name"}},
+				},
+			},
+		},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	v := hcler.Map{
+		"region": expr.Ref("var", "region"),
+		"job": hcler.Block{Labels: []string{"web"}, Body: hcler.Map{
+			"datacenters": hcler.List{"dc1", expr.Ref("var", "dc")},
+			"priority":    "50",
+			"meta":        map[interface{}]interface{}{"team": "infra"},
+			"any":         hcler.List{1, "a"},
+			"group":       hcler.Blocks{{Labels: []string{"a"}, Body: hcler.Map{"anything": 1}}, {Labels: []string{"b"}}},
+		}},
+	}
+	require.NoError(t, hcler.Validate(v, testSchema))
+	require.NoError(t, hcler.Validate(nil, nil))
+}
+
+func TestValidateViolations(t *testing.T) {
+	v := hcler.Map{
+		"regoin": "us-east-1",
+		"job": hcler.Blocks{
+			{Labels: []string{"web"}, Body: hcler.Map{
+				"datacenters": "dc1",
+				"priority":    "high",
+				"meta":        hcler.Block{},
+				"service":     hcler.Map{"port": 80, "prot": "tcp"},
+				"group":       hcler.Block{Labels: []string{"a", "b"}},
+			}},
+			{Labels: []string{"api"}, Body: hcler.List{}},
+		},
+	}
+	err := hcler.Validate(v, testSchema)
+	require.Error(t, err)
+	verr, ok := err.(*hcler.ValidationError)
+	require.True(t, ok)
+	assert.Equal(t, []hcler.Violation{
+		{Path: "job", Message: "at most 1 block(s) allowed, got 2"},
+		{Path: "job.web.datacenters", Message: "invalid value: list of string required"},
+		{Path: "job.web.group.a.b", Message: "expected 1 label(s) (name), got 2"},
+		{Path: "job.web.meta", Message: "expected an argument, got a block"},
+		{Path: "job.web.priority", Message: "invalid value: number required"},
+		{Path: "job.web.service", Message: "unsupported attribute prot"},
+		{Path: "job.api", Message: "expected a body, got hcler.List"},
+		{Path: "regoin", Message: "unsupported argument or block"},
+		{Path: "region", Message: "missing required argument"},
+	}, verr.Violations)
+	assert.Contains(t, err.Error(), "schema validation: job: at most 1 block(s) allowed, got 2; job.web.datacenters:")
+
+	err = hcler.Validate(hcler.Map{"region": hcler.Map{"a": 1}}, testSchema)
+	require.Error(t, err)
+	assert.Equal(t, []hcler.Violation{
+		{Path: "region", Message: "invalid value: string required"},
+		{Path: "job", Message: "at least 1 block(s) required, got 0"},
+	}, err.(*hcler.ValidationError).Violations)
+
+	err = hcler.Validate(hcler.Map{"region": "a", "job": "web"}, testSchema)
+	require.Error(t, err)
+	assert.Equal(t, "schema validation: job: expected a block, got string", err.Error())
+}
+
+func TestValidateRequiredNull(t *testing.T) {
+	schema := &hcler.Schema{Attributes: map[string]hcler.AttributeSchema{
+		"region": {Type: cty.String, Required: true},
+		"zone":   {Type: cty.String},
+	}}
+	for name, v := range map[string]interface{}{
+		"null":     hcler.Null,
+		"cty_null": cty.NullVal(cty.String),
+	} {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			err := hcler.Validate(hcler.Map{"region": v, "zone": v}, schema)
+			require.Error(t, err)
+			assert.Equal(t, "schema validation: region: missing required argument", err.Error())
+		})
+	}
+}
+
+func TestApplySchema(t *testing.T) {
+	schema := &hcler.Schema{
+		Attributes: map[string]hcler.AttributeSchema{"region": {Type: cty.String}},
+		Blocks: map[string]hcler.BlockSchema{
+			"resource": {Labels: []string{"type", "name"}, Body: &hcler.Schema{
+				Attributes: map[string]hcler.AttributeSchema{"ami": {Type: cty.String}},
+				Blocks:     map[string]hcler.BlockSchema{"tag": {}},
+			}},
+		},
+	}
+	v := hcler.OrderedMap{
+		{Key: "region", Value: "us-east-1"},
+		{Key: "resource", Value: hcler.Map{"aws_instance": hcler.Map{
+			"web": hcler.Map{"ami": "abc", "tag": hcler.List{hcler.Map{}, hcler.Map{}}},
+			"db":  hcler.OrderedMap{},
+		}}},
+	}
+	got, err := hcler.ApplySchema(v, schema)
+	require.NoError(t, err)
+	assert.Equal(t, hcler.OrderedMap{
+		{Key: "region", Value: "us-east-1"},
+		{Key: "resource", Value: hcler.Blocks{
+			{Labels: []string{"aws_instance", "db"}, Body: hcler.OrderedMap{}},
+			{Labels: []string{"aws_instance", "web"}, Body: hcler.Map{"ami": "abc", "tag": hcler.Blocks{{Body: hcler.Map{}}, {Body: hcler.Map{}}}}},
+		}},
+	}, got)
+
+	_, err = hcler.ApplySchema(hcler.Map{"resource": hcler.Map{"aws_instance": "web"}}, schema)
+	require.Error(t, err)
+	assert.Equal(t, "schema validation: resource: expected a block, got hcler.Map", err.Error())
+}
@@ -0,0 +1,223 @@
+package hcler
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Template builds an HCL2 template string mixing literal parts,
+// which are escaped, with interpolations and directives.
+// The zero value is an empty template ready to use.
+type Template struct {
+	parts []templatePart
+	err   error
+}
+
+type partKind int
+
+const (
+	partLit partKind = iota
+	partInterp
+	partFor
+	partIf
+)
+
+// templatePart is one of literal, interpolation or directive.
+type templatePart struct {
+	kind partKind
+	lit  string
+
+	// value is the interpolated value, the for collection or the if condition.
+	value interface{}
+
+	// Directives.
+	key, name string // For variables.
+	body, els *Template
+}
+
+// NewTemplate creates a new template starting with the given literal parts.
+func NewTemplate(lits ...string) *Template {
+	t := &Template{}
+	for _, s := range lits {
+		t.Lit(s)
+	}
+	return t
+}
+
+// Lit appends a literal string. It will be escaped when encoded.
+func (t *Template) Lit(s string) *Template {
+	t.parts = append(t.parts, templatePart{kind: partLit, lit: s})
+	return t
+}
+
+// Interp appends an interpolation of the given value: `${v}`.
+func (t *Template) Interp(v interface{}) *Template {
+	t.parts = append(t.parts, templatePart{kind: partInterp, value: v})
+	return t
+}
+
+// For appends a for directive rendering body for each element of coll:
+// `%{ for key, value in coll }body%{ endfor }`. key is optional.
+func (t *Template) For(key, value string, coll interface{}, body *Template) *Template {
+	if t.err == nil && key != "" && !IsIdentifier(key) {
+		t.err = errors.Errorf("invalid for key variable %q", key)
+	}
+	if t.err == nil && !IsIdentifier(value) {
+		t.err = errors.Errorf("invalid for value variable %q", value)
+	}
+	t.parts = append(t.parts, templatePart{kind: partFor, key: key, name: value, value: coll, body: body})
+	return t
+}
+
+// If appends an if directive: `%{ if cond }then%{ else }els%{ endif }`.
+// els is optional.
+func (t *Template) If(cond interface{}, then, els *Template) *Template {
+	t.parts = append(t.parts, templatePart{kind: partIf, value: cond, body: then, els: els})
+	return t
+}
+
+// EncodeHCL implements the hcl.Encoder interface.
+// Renders the template as a quoted string.
+func (t *Template) EncodeHCL() (string, error) {
+	s, err := t.render(false, false)
+	if err != nil {
+		return "", err
+	}
+	return `"` + s + `"`, nil
+}
+
+// Heredoc returns an Encoder rendering the template as a heredoc
+// delimited by marker.
+func (t *Template) Heredoc(marker string) Encoder {
+	return heredoc{t: t, marker: marker}
+}
+
+type heredoc struct {
+	t      *Template
+	marker string
+}
+
+// EncodeHCL implements the hcl.Encoder interface.
+func (h heredoc) EncodeHCL() (string, error) {
+	if !IsIdentifier(h.marker) {
+		return "", errors.Errorf("invalid heredoc marker %q", h.marker)
+	}
+	s, err := h.t.render(true, false)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == h.marker {
+			return "", errors.Errorf("heredoc body contains its marker %q", h.marker)
+		}
+	}
+	// The closing marker must be followed by a newline.
+	return "<<" + h.marker + "\n" + s + h.marker + "\n", nil
+}
+
+var (
+	quotedReplacer = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	heredocReplacer = strings.NewReplacer(
+		"${", "$${",
+		"%{", "%%{",
+	)
+)
+
+// render the template body, escaping literals for a quoted string
+// or a heredoc. nested is set for directive bodies, which are followed
+// by the closing directive.
+// nolint: gosec
+func (t *Template) render(heredoc, nested bool) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	if t.err != nil {
+		return "", t.err
+	}
+	replacer := quotedReplacer
+	if heredoc {
+		replacer = heredocReplacer
+	}
+
+	var b, lit strings.Builder
+	// Literals are escaped as a whole, `$` and `{` may be split across parts.
+	flush := func(beforeTag bool) {
+		_, _ = b.WriteString(escapeLiteral(replacer, lit.String(), beforeTag))
+		lit.Reset()
+	}
+	for _, p := range t.parts {
+		if p.kind == partLit {
+			_, _ = lit.WriteString(p.lit)
+			continue
+		}
+		flush(true)
+		switch p.kind {
+		case partFor:
+			in, err := Encode(p.value)
+			if err != nil {
+				return "", errors.Wrap(err, "encode for collection")
+			}
+			body, err := p.body.render(heredoc, true)
+			if err != nil {
+				return "", errors.Wrap(err, "render for body")
+			}
+			_, _ = b.WriteString("%{ for ")
+			if p.key != "" {
+				_, _ = b.WriteString(p.key + ", ")
+			}
+			_, _ = b.WriteString(p.name + " in " + in + " }" + body + "%{ endfor }")
+		case partIf:
+			cond, err := Encode(p.value)
+			if err != nil {
+				return "", errors.Wrap(err, "encode if condition")
+			}
+			then, err := p.body.render(heredoc, true)
+			if err != nil {
+				return "", errors.Wrap(err, "render if body")
+			}
+			_, _ = b.WriteString("%{ if " + cond + " }" + then)
+			if p.els != nil {
+				els, err := p.els.render(heredoc, true)
+				if err != nil {
+					return "", errors.Wrap(err, "render else body")
+				}
+				_, _ = b.WriteString("%{ else }" + els)
+			}
+			_, _ = b.WriteString("%{ endif }")
+		case partInterp:
+			s, err := Encode(p.value)
+			if err != nil {
+				return "", errors.Wrap(err, "encode interpolation")
+			}
+			_, _ = b.WriteString("${" + s + "}")
+		}
+	}
+	flush(nested)
+	return b.String(), nil
+}
+
+// escapeLiteral escapes the given literal. When followed by an
+// interpolation or a directive, trailing `$` and `%` would form an
+// escape sequence with it and are interpolated as a string instead.
+func escapeLiteral(replacer *strings.Replacer, s string, beforeTag bool) string {
+	if !beforeTag {
+		return replacer.Replace(s)
+	}
+	trimmed := strings.TrimRight(s, "$%")
+	if trimmed == s {
+		return replacer.Replace(s)
+	}
+	return replacer.Replace(trimmed) + `${"` + s[len(trimmed):] + `"}`
+}
@@ -0,0 +1,110 @@
+package hcler_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ hcler.Encoder = (*hcler.Template)(nil)
+	_ hcler.Encoder = hcler.NewTemplate().Heredoc("EOT")
+)
+
+func TestTemplate(t *testing.T) {
+	t.Run("interpolation", func(t *testing.T) {
+		tpl := hcler.NewTemplate().
+			Interp(expr.Ref("var", "prefix")).
+			Lit("-web-").
+			Interp(expr.Ref("count", "index"))
+		assertEncoding([]string{`"${var.prefix}-web-${count.index}"`}, tpl)(t)
+	})
+
+	t.Run("escaping", func(t *testing.T) {
+		tpl := hcler.NewTemplate(`say "hi" \o/`, "\n", "${not} %{ a directive }")
+		assertEncoding([]string{`"say \"hi\" \\o/\n$${not} %%{ a directive }"`}, tpl)(t)
+	})
+
+	t.Run("for_directive", func(t *testing.T) {
+		tpl := hcler.NewTemplate("hosts:").
+			For("", "h", expr.Ref("var", "hosts"), hcler.NewTemplate(" ").Interp(expr.Ref("h")))
+		assertEncoding([]string{`"hosts:%{ for h in var.hosts } ${h}%{ endfor }"`}, tpl)(t)
+	})
+
+	t.Run("if_directive", func(t *testing.T) {
+		tpl := hcler.NewTemplate().
+			If(expr.Ref("var", "prod"), hcler.NewTemplate("prod"), hcler.NewTemplate("dev"))
+		assertEncoding([]string{`"%{ if var.prod }prod%{ else }dev%{ endif }"`}, tpl)(t)
+
+		tpl = hcler.NewTemplate().If(expr.Ref("var", "prod"), hcler.NewTemplate("prod"), nil)
+		assertEncoding([]string{`"%{ if var.prod }prod%{ endif }"`}, tpl)(t)
+	})
+
+	t.Run("heredoc", func(t *testing.T) {
+		tpl := hcler.NewTemplate(`#!/bin/sh`, "\n", `echo "`).Interp(expr.Ref("var", "msg")).Lit(`" ${HOME}`)
+		assertEncoding([]string{"<<EOT\n#!/bin/sh\necho \"${var.msg}\" $${HOME}\nEOT\n"}, tpl.Heredoc("EOT"))(t)
+	})
+
+	t.Run("in_map", func(t *testing.T) {
+		m := hcler.Map{"name": hcler.NewTemplate("web-").Interp(expr.Ref("count", "index"))}
+		assertEncoding([]string{`{ name = "web-${count.index}" }`}, m)(t)
+
+		m = hcler.Map{"a": hcler.NewTemplate("x").Heredoc("EOT"), "b": 1}
+		assertEncoding([]string{"{ a = <<EOT\nx\nEOT\nb = 1 }", "{ b = 1, a = <<EOT\nx\nEOT\n }"}, m)(t)
+	})
+}
+
+func TestTemplateBoundaries(t *testing.T) {
+	for name, tc := range map[string]struct {
+		tpl    *hcler.Template
+		expect string
+	}{
+		"dollar_interp":      {tpl: hcler.NewTemplate("cost: $").Interp(1), expect: "cost: $1"},
+		"dollars_interp":     {tpl: hcler.NewTemplate("a$$").Interp(1), expect: "a$$1"},
+		"split_interp":       {tpl: hcler.NewTemplate("$", "{x}"), expect: "${x}"},
+		"split_directive":    {tpl: hcler.NewTemplate("%", "{x}"), expect: "%{x}"},
+		"percent_directive":  {tpl: hcler.NewTemplate("100%").If(true, hcler.NewTemplate("!"), nil), expect: "100%!"},
+		"dollar_in_body":     {tpl: hcler.NewTemplate().If(true, hcler.NewTemplate("$"), hcler.NewTemplate("%")), expect: "$"},
+		"dollar_in_for_body": {tpl: hcler.NewTemplate().For("", "x", hcler.List{1, 2}, hcler.NewTemplate().Interp(expr.Ref("x")).Lit("$")), expect: "1$2$"},
+		"trailing_dollar":    {tpl: hcler.NewTemplate("a$"), expect: "a$"},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			for _, enc := range []hcler.Encoder{tc.tpl, tc.tpl.Heredoc("EOT")} {
+				s, err := enc.EncodeHCL()
+				require.NoError(t, err)
+				e, diags := hclsyntax.ParseExpression([]byte(s), "", hcl.InitialPos)
+				require.False(t, diags.HasErrors(), "%s: %s", s, diags.Error())
+				v, diags := e.Value(nil)
+				require.False(t, diags.HasErrors(), "%s: %s", s, diags.Error())
+				got := v.AsString()
+				if _, ok := enc.(*hcler.Template); !ok {
+					got = got[:len(got)-1] // Heredoc trailing newline.
+				}
+				assert.Equal(t, tc.expect, got, s)
+			}
+		})
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	for name, v := range map[string]hcler.Encoder{
+		"for_variable":   hcler.NewTemplate().For("", "1x", hcler.List{}, nil),
+		"for_key":        hcler.NewTemplate().For("k k", "x", hcler.List{}, nil),
+		"interpolation":  hcler.NewTemplate().Interp(expr.Ref("-")),
+		"heredoc_marker": hcler.NewTemplate("foo").Heredoc("E O T"),
+		"heredoc_body":   hcler.NewTemplate("a\nEOT\nb").Heredoc("EOT"),
+	} {
+		v := v
+		t.Run(name, func(t *testing.T) {
+			_, err := hcler.Encode(v)
+			require.Error(t, err)
+		})
+	}
+	assert.NotPanics(t, func() { _, _ = (*hcler.Template)(nil).EncodeHCL() })
+}
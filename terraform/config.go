@@ -0,0 +1,481 @@
+package terraform
+
+import (
+	"sort"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+)
+
+// Config is a Terraform configuration file.
+// Blocks are rendered in the conventional order: terraform, provider,
+// variable, locals, data, resource, module then output.
+type Config struct {
+	Terraform *Settings
+	Providers []ProviderConfig
+	Variables []Variable
+	Locals    interface{} // Map-like, see Local.
+	Data      []Data
+	Resources []Resource
+	Modules   []Module
+	Outputs   []Output
+}
+
+// blockBuilder is implemented by the block types of a Config.
+type blockBuilder interface {
+	Block() (hcler.Block, error)
+}
+
+// locals builds the `locals` block.
+type locals struct{ body interface{} }
+
+func (l locals) Block() (hcler.Block, error) { return hcler.Block{Body: l.body}, nil }
+
+// File renders the configuration as a hclwrite.File.
+func (c *Config) File() (*hclwrite.File, error) {
+	type namedBuilder struct {
+		typ string
+		blockBuilder
+	}
+	var builders []namedBuilder
+	if c.Terraform != nil {
+		builders = append(builders, namedBuilder{"terraform", c.Terraform})
+	}
+	for _, p := range c.Providers {
+		builders = append(builders, namedBuilder{"provider", p})
+	}
+	for _, v := range c.Variables {
+		builders = append(builders, namedBuilder{"variable", v})
+	}
+	if c.Locals != nil {
+		builders = append(builders, namedBuilder{"locals", locals{c.Locals}})
+	}
+	for _, d := range c.Data {
+		builders = append(builders, namedBuilder{"data", d})
+	}
+	for _, r := range c.Resources {
+		builders = append(builders, namedBuilder{"resource", r})
+	}
+	for _, m := range c.Modules {
+		builders = append(builders, namedBuilder{"module", m})
+	}
+	for _, o := range c.Outputs {
+		builders = append(builders, namedBuilder{"output", o})
+	}
+
+	f := hclwrite.NewEmptyFile()
+	for i, nb := range builders {
+		b, err := nb.Block()
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			f.Body().AppendNewline()
+		}
+		if err := hcler.ToBody(f.Body(), hcler.Map{nb.typ: b}); err != nil {
+			return nil, errors.Wrapf(err, "%s %q", nb.typ, b.Labels)
+		}
+	}
+	return f, nil
+}
+
+// Encode renders the configuration as a formatted HCL document.
+func (c *Config) Encode() (string, error) {
+	f, err := c.File()
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.Format(f.Bytes())), nil
+}
+
+// Settings is the `terraform` block.
+type Settings struct {
+	RequiredVersion   string
+	RequiredProviders map[string]RequiredProvider
+	Backend           *Backend
+}
+
+// RequiredProvider is an entry of `required_providers`.
+type RequiredProvider struct {
+	Source  string
+	Version string
+}
+
+// Backend is a `backend "type"` block.
+type Backend struct {
+	Type   string
+	Config interface{} // Map-like.
+}
+
+// Block builds the `terraform` block.
+func (s Settings) Block() (hcler.Block, error) {
+	var body hcler.OrderedMap
+	if s.RequiredVersion != "" {
+		body.Set("required_version", s.RequiredVersion)
+	}
+	if len(s.RequiredProviders) > 0 {
+		names := make([]string, 0, len(s.RequiredProviders))
+		for name := range s.RequiredProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var providers hcler.OrderedMap
+		for _, name := range names {
+			var p hcler.OrderedMap
+			setString(&p, "source", s.RequiredProviders[name].Source)
+			setString(&p, "version", s.RequiredProviders[name].Version)
+			providers.Set(name, p)
+		}
+		body.Set("required_providers", hcler.Block{Body: providers})
+	}
+	if s.Backend != nil {
+		if !hcler.IsIdentifier(s.Backend.Type) {
+			return hcler.Block{}, errors.Errorf("invalid backend type %q", s.Backend.Type)
+		}
+		body.Set("backend", hcler.Block{Labels: []string{s.Backend.Type}, Body: s.Backend.Config})
+	}
+	return hcler.Block{Body: body}, nil
+}
+
+// ProviderConfig is a `provider "name"` block.
+type ProviderConfig struct {
+	Name  string
+	Alias string
+	Body  interface{} // Map-like provider specific arguments.
+}
+
+// Block builds the `provider` block.
+func (p ProviderConfig) Block() (hcler.Block, error) {
+	if err := checkName("provider", p.Name); err != nil {
+		return hcler.Block{}, err
+	}
+	var body hcler.OrderedMap
+	setString(&body, "alias", p.Alias)
+	if err := appendBody(&body, p.Body); err != nil {
+		return hcler.Block{}, errors.Wrapf(err, "provider %q", p.Name)
+	}
+	return hcler.Block{Labels: []string{p.Name}, Body: body}, nil
+}
+
+// Ref references the provider configuration, i.e. `aws.west`,
+// for the Provider meta-argument.
+func (p ProviderConfig) Ref() expr.Expr {
+	if p.Alias == "" {
+		return expr.Ref(p.Name)
+	}
+	return expr.Ref(p.Name, p.Alias)
+}
+
+// Meta holds the meta-arguments of resources, data sources and modules.
+type Meta struct {
+	Count     interface{} // Number or expression.
+	ForEach   interface{} // Map, set or expression.
+	Provider  hcler.Encoder
+	DependsOn []hcler.Encoder
+	Lifecycle *Lifecycle
+}
+
+// Lifecycle is the `lifecycle` block.
+type Lifecycle struct {
+	CreateBeforeDestroy bool
+	PreventDestroy      bool
+	IgnoreChanges       []hcler.Encoder // Attribute references, i.e. expr.Ref("tags").
+	IgnoreAllChanges    bool
+	ReplaceTriggeredBy  []hcler.Encoder
+	Preconditions       []Condition
+	Postconditions      []Condition
+}
+
+// Condition is a custom condition: `precondition`, `postcondition`
+// or `validation` block.
+type Condition struct {
+	Condition    hcler.Encoder
+	ErrorMessage string
+}
+
+func (c Condition) block() hcler.Block {
+	return hcler.Block{Body: hcler.OrderedMap{
+		{Key: "condition", Value: c.Condition},
+		{Key: "error_message", Value: c.ErrorMessage},
+	}}
+}
+
+func conditions(cs []Condition) hcler.Blocks {
+	out := make(hcler.Blocks, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.block())
+	}
+	return out
+}
+
+// head sets the meta-arguments conventionally written first.
+func (m Meta) head(body *hcler.OrderedMap) {
+	if m.Count != nil {
+		body.Set("count", m.Count)
+	}
+	if m.ForEach != nil {
+		body.Set("for_each", m.ForEach)
+	}
+	if m.Provider != nil {
+		body.Set("provider", m.Provider)
+	}
+}
+
+// tail sets the meta-arguments conventionally written last.
+func (m Meta) tail(body *hcler.OrderedMap) {
+	setRefs(body, "depends_on", m.DependsOn)
+	if m.Lifecycle != nil {
+		body.Set("lifecycle", m.Lifecycle.block())
+	}
+}
+
+func (l Lifecycle) block() hcler.Block {
+	var body hcler.OrderedMap
+	setBool(&body, "create_before_destroy", l.CreateBeforeDestroy)
+	setBool(&body, "prevent_destroy", l.PreventDestroy)
+	if l.IgnoreAllChanges {
+		body.Set("ignore_changes", expr.Ref("all"))
+	} else {
+		setRefs(&body, "ignore_changes", l.IgnoreChanges)
+	}
+	setRefs(&body, "replace_triggered_by", l.ReplaceTriggeredBy)
+	if len(l.Preconditions) > 0 {
+		body.Set("precondition", conditions(l.Preconditions))
+	}
+	if len(l.Postconditions) > 0 {
+		body.Set("postcondition", conditions(l.Postconditions))
+	}
+	return hcler.Block{Body: body}
+}
+
+// Resource is a `resource "type" "name"` block.
+type Resource struct {
+	Type string
+	Name string
+	Meta
+	Body interface{} // Map-like provider specific arguments and blocks.
+}
+
+// Block builds the `resource` block.
+func (r Resource) Block() (hcler.Block, error) {
+	body, err := metaBody("resource", r.Type, r.Name, r.Meta, r.Body)
+	return hcler.Block{Labels: []string{r.Type, r.Name}, Body: body}, err
+}
+
+// Ref references the resource or one of its attributes, i.e. `aws_instance.web.id`.
+func (r Resource) Ref(attrs ...string) expr.Expr {
+	return expr.Ref(r.Type, append([]string{r.Name}, attrs...)...)
+}
+
+// Data is a `data "type" "name"` block.
+type Data struct {
+	Type string
+	Name string
+	Meta
+	Body interface{} // Map-like provider specific arguments and blocks.
+}
+
+// Block builds the `data` block.
+func (d Data) Block() (hcler.Block, error) {
+	body, err := metaBody("data", d.Type, d.Name, d.Meta, d.Body)
+	return hcler.Block{Labels: []string{d.Type, d.Name}, Body: body}, err
+}
+
+// Ref references the data source or one of its attributes, i.e. `data.aws_ami.ubuntu.id`.
+func (d Data) Ref(attrs ...string) expr.Expr {
+	return expr.Ref("data", append([]string{d.Type, d.Name}, attrs...)...)
+}
+
+func metaBody(kind, typ, name string, meta Meta, extra interface{}) (hcler.OrderedMap, error) {
+	if err := checkName(kind+" type", typ); err != nil {
+		return nil, err
+	}
+	if err := checkName(kind+" name", name); err != nil {
+		return nil, err
+	}
+	var body hcler.OrderedMap
+	meta.head(&body)
+	if err := appendBody(&body, extra); err != nil {
+		return nil, errors.Wrapf(err, "%s %s.%s", kind, typ, name)
+	}
+	meta.tail(&body)
+	return body, nil
+}
+
+// Module is a `module "name"` block.
+type Module struct {
+	Name    string
+	Source  string
+	Version string
+	Meta
+	Providers map[string]hcler.Encoder // Provider configurations passed to the module, i.e. {"aws": expr.Ref("aws", "west")}.
+	Inputs    interface{}              // Map-like input variables.
+}
+
+// Block builds the `module` block.
+func (m Module) Block() (hcler.Block, error) {
+	if err := checkName("module", m.Name); err != nil {
+		return hcler.Block{}, err
+	}
+	var body hcler.OrderedMap
+	body.Set("source", m.Source)
+	setString(&body, "version", m.Version)
+	m.head(&body)
+	if len(m.Providers) > 0 {
+		providers := hcler.Map{}
+		for k, v := range m.Providers {
+			providers[k] = v
+		}
+		body.Set("providers", providers)
+	}
+	if err := appendBody(&body, m.Inputs); err != nil {
+		return hcler.Block{}, errors.Wrapf(err, "module %q", m.Name)
+	}
+	m.tail(&body)
+	return hcler.Block{Labels: []string{m.Name}, Body: body}, nil
+}
+
+// Ref references the module or one of its outputs, i.e. `module.vpc.id`.
+func (m Module) Ref(outputs ...string) expr.Expr {
+	return expr.Ref("module", append([]string{m.Name}, outputs...)...)
+}
+
+// Variable is a `variable "name"` block.
+type Variable struct {
+	Name        string
+	Type        hcler.Encoder // Type constraint, i.e. expr.Call("list", expr.Ref("string")).
+	Default     interface{}
+	Description string
+	Sensitive   bool
+	Nullable    *bool
+	Validations []Condition
+}
+
+// Block builds the `variable` block.
+func (v Variable) Block() (hcler.Block, error) {
+	if err := checkName("variable", v.Name); err != nil {
+		return hcler.Block{}, err
+	}
+	var body hcler.OrderedMap
+	if v.Type != nil {
+		body.Set("type", v.Type)
+	}
+	setString(&body, "description", v.Description)
+	if v.Default != nil {
+		body.Set("default", v.Default)
+	}
+	setBool(&body, "sensitive", v.Sensitive)
+	if v.Nullable != nil {
+		body.Set("nullable", expr.Bool(*v.Nullable))
+	}
+	if len(v.Validations) > 0 {
+		body.Set("validation", conditions(v.Validations))
+	}
+	return hcler.Block{Labels: []string{v.Name}, Body: body}, nil
+}
+
+// Ref references the variable, i.e. `var.region`.
+func (v Variable) Ref(attrs ...string) expr.Expr {
+	return expr.Ref("var", append([]string{v.Name}, attrs...)...)
+}
+
+// Output is an `output "name"` block.
+type Output struct {
+	Name          string
+	Value         interface{}
+	Description   string
+	Sensitive     bool
+	DependsOn     []hcler.Encoder
+	Preconditions []Condition
+}
+
+// Block builds the `output` block.
+func (o Output) Block() (hcler.Block, error) {
+	if err := checkName("output", o.Name); err != nil {
+		return hcler.Block{}, err
+	}
+	var body hcler.OrderedMap
+	setString(&body, "description", o.Description)
+	body.Set("value", o.Value)
+	setBool(&body, "sensitive", o.Sensitive)
+	setRefs(&body, "depends_on", o.DependsOn)
+	if len(o.Preconditions) > 0 {
+		body.Set("precondition", conditions(o.Preconditions))
+	}
+	return hcler.Block{Labels: []string{o.Name}, Body: body}, nil
+}
+
+// Local references a local value, i.e. `local.name`.
+func Local(name string, attrs ...string) expr.Expr {
+	return expr.Ref("local", append([]string{name}, attrs...)...)
+}
+
+func checkName(kind, name string) error {
+	if !hcler.IsIdentifier(name) {
+		return errors.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+func setString(body *hcler.OrderedMap, k, v string) {
+	if v != "" {
+		body.Set(k, v)
+	}
+}
+
+func setBool(body *hcler.OrderedMap, k string, v bool) {
+	if v {
+		body.Set(k, expr.Bool(true))
+	}
+}
+
+func setRefs(body *hcler.OrderedMap, k string, refs []hcler.Encoder) {
+	if len(refs) == 0 {
+		return
+	}
+	l := make(hcler.List, 0, len(refs))
+	for _, r := range refs {
+		l = append(l, r)
+	}
+	body.Set(k, l)
+}
+
+// appendBody appends the entries of the given Map-like body, in order
+// for hcler.OrderedMap, sorted by key otherwise.
+func appendBody(body *hcler.OrderedMap, v interface{}) error {
+	var m hcler.Map
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case hcler.OrderedMap:
+		for _, kv := range v {
+			body.Set(kv.Key, kv.Value)
+		}
+		return nil
+	case hcler.Map:
+		m = v
+	case map[string]interface{}:
+		m = v
+	case hcler.IMap:
+		out, err := v.Map()
+		if err != nil {
+			return err
+		}
+		m = out
+	case map[interface{}]interface{}:
+		return appendBody(body, hcler.IMap(v))
+	default:
+		return errors.Errorf("unsupported body type %T", v)
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		body.Set(k, m[k])
+	}
+	return nil
+}
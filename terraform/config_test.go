@@ -0,0 +1,199 @@
+package terraform_test
+
+import (
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/creack/hcler/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	west := terraform.ProviderConfig{Name: "aws", Alias: "west", Body: hcler.Map{"region": "us-west-2"}}
+	region := terraform.Variable{
+		Name:        "region",
+		Type:        expr.Ref("string"),
+		Description: "AWS region.",
+		Default:     "us-east-1",
+		Validations: []terraform.Condition{{
+			Condition:    expr.Call("startswith", expr.Ref("var", "region"), "us-"),
+			ErrorMessage: "US regions only.",
+		}},
+	}
+	ami := terraform.Data{Type: "aws_ami", Name: "ubuntu", Body: hcler.Map{"most_recent": expr.Bool(true)}}
+	web := terraform.Resource{
+		Type: "aws_instance",
+		Name: "web",
+		Meta: terraform.Meta{
+			Count:     3,
+			Provider:  west.Ref(),
+			DependsOn: []hcler.Encoder{expr.Ref("aws_iam_role", "web")},
+			Lifecycle: &terraform.Lifecycle{
+				CreateBeforeDestroy: true,
+				IgnoreChanges:       []hcler.Encoder{expr.Ref("tags")},
+			},
+		},
+		Body: hcler.OrderedMap{
+			{Key: "ami", Value: ami.Ref("id")},
+			{Key: "instance_type", Value: terraform.Local("size")},
+		},
+	}
+	vpc := terraform.Module{
+		Name:      "vpc",
+		Source:    "terraform-aws-modules/vpc/aws",
+		Version:   "~> 5.0",
+		Providers: map[string]hcler.Encoder{"aws": west.Ref()},
+		Inputs:    hcler.Map{"cidr": "10.0.0.0/16"},
+	}
+	cfg := &terraform.Config{
+		Terraform: &terraform.Settings{
+			RequiredVersion:   ">= 1.5",
+			RequiredProviders: map[string]terraform.RequiredProvider{"aws": {Source: "hashicorp/aws", Version: "~> 5.0"}},
+			Backend:           &terraform.Backend{Type: "s3", Config: hcler.Map{"bucket": "state"}},
+		},
+		Providers: []terraform.ProviderConfig{{Name: "aws", Body: hcler.Map{"region": region.Ref()}}, west},
+		Variables: []terraform.Variable{region},
+		Locals:    hcler.Map{"size": "t3.micro"},
+		Data:      []terraform.Data{ami},
+		Resources: []terraform.Resource{web},
+		Modules:   []terraform.Module{vpc},
+		Outputs:   []terraform.Output{{Name: "ids", Value: expr.Attr(expr.Index(web.Ref(), 0), "id"), Sensitive: true}},
+	}
+
+	got, err := cfg.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, `terraform {
+  required_version = ">= 1.5"
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+  backend "s3" {
+    bucket = "state"
+  }
+}
+
+provider "aws" {
+  region = var.region
+}
+
+provider "aws" {
+  alias  = "west"
+  region = "us-west-2"
+}
+
+variable "region" {
+  type        = string
+  description = "AWS region."
+  default     = "us-east-1"
+  validation {
+    condition     = startswith(var.region, "us-")
+    error_message = "US regions only."
+  }
+}
+
+locals {
+  size = "t3.micro"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_instance" "web" {
+  count         = 3
+  provider      = aws.west
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = local.size
+  depends_on    = [aws_iam_role.web]
+  lifecycle {
+    create_before_destroy = true
+    ignore_changes        = [tags]
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.0"
+  providers = {
+    aws = aws.west
+  }
+  cidr = "10.0.0.0/16"
+}
+
+output "ids" {
+  value     = aws_instance.web[0].id
+  sensitive = true
+}
+`, got)
+}
+
+func TestConfigError(t *testing.T) {
+	for name, cfg := range map[string]*terraform.Config{
+		"resource_type": {Resources: []terraform.Resource{{Type: "aws instance", Name: "web"}}},
+		"resource_name": {Resources: []terraform.Resource{{Type: "aws_instance", Name: "1web"}}},
+		"resource_body": {Resources: []terraform.Resource{{Type: "aws_instance", Name: "web", Body: hcler.List{}}}},
+		"data_name":     {Data: []terraform.Data{{Type: "aws_ami", Name: ""}}},
+		"provider":      {Providers: []terraform.ProviderConfig{{Name: "a.b"}}},
+		"module":        {Modules: []terraform.Module{{Name: "vpc", Inputs: hcler.IMap{1: 1, "1": 2}}}},
+		"variable":      {Variables: []terraform.Variable{{Name: "a b"}}},
+		"output":        {Outputs: []terraform.Output{{Name: "id", Value: expr.Ref("-")}}},
+		"backend":       {Terraform: &terraform.Settings{Backend: &terraform.Backend{Type: "s 3"}}},
+		"locals":        {Locals: hcler.Map{"a b": 1}},
+	} {
+		cfg := cfg
+		t.Run(name, func(t *testing.T) {
+			_, err := cfg.Encode()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMetaArguments(t *testing.T) {
+	b, err := terraform.Resource{
+		Type: "aws_instance",
+		Name: "web",
+		Meta: terraform.Meta{
+			ForEach: expr.Ref("var", "instances"),
+			Lifecycle: &terraform.Lifecycle{
+				PreventDestroy:     true,
+				IgnoreAllChanges:   true,
+				ReplaceTriggeredBy: []hcler.Encoder{expr.Ref("null_resource", "trigger")},
+				Preconditions:      []terraform.Condition{{Condition: expr.Ref("var", "ok"), ErrorMessage: "not ok"}},
+				Postconditions:     []terraform.Condition{{Condition: expr.Ref("self", "ok"), ErrorMessage: "still not ok"}},
+			},
+		},
+	}.Block()
+	require.NoError(t, err)
+
+	got, err := hcler.EncodeBody(hcler.Map{"resource": b})
+	require.NoError(t, err)
+	assert.Equal(t, `resource "aws_instance" "web" {
+  for_each = var.instances
+  lifecycle {
+    prevent_destroy      = true
+    ignore_changes       = all
+    replace_triggered_by = [null_resource.trigger]
+    precondition {
+      condition     = var.ok
+      error_message = "not ok"
+    }
+    postcondition {
+      condition     = self.ok
+      error_message = "still not ok"
+    }
+  }
+}
+`, got)
+
+	nullable := false
+	b, err = terraform.Variable{Name: "tags", Nullable: &nullable, Sensitive: true}.Block()
+	require.NoError(t, err)
+	got, err = hcler.EncodeBody(hcler.Map{"variable": b})
+	require.NoError(t, err)
+	assert.Equal(t, "variable \"tags\" {\n  sensitive = true\n  nullable  = false\n}\n", got)
+}
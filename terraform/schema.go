@@ -0,0 +1,238 @@
+// Package terraform helps generating Terraform configurations with hcler.
+package terraform
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Provider holds the schemas of a provider.
+type Provider struct {
+	Schema      *hcler.Schema
+	Resources   map[string]*hcler.Schema
+	DataSources map[string]*hcler.Schema
+}
+
+// ProviderSchemas are the provider schemas dumped by
+// `terraform providers schema -json`, keyed by provider source address,
+// i.e. "registry.terraform.io/hashicorp/aws".
+//
+// Resource and data source schemas include the meta-arguments
+// (count, for_each, depends_on, provider, lifecycle, ...).
+type ProviderSchemas map[string]*Provider
+
+// LoadProviderSchemas reads the output of `terraform providers schema -json`.
+func LoadProviderSchemas(r io.Reader) (ProviderSchemas, error) {
+	var doc struct {
+		FormatVersion   string `json:"format_version"`
+		ProviderSchemas map[string]struct {
+			Provider          *schemaJSON            `json:"provider"`
+			ResourceSchemas   map[string]*schemaJSON `json:"resource_schemas"`
+			DataSourceSchemas map[string]*schemaJSON `json:"data_source_schemas"`
+		} `json:"provider_schemas"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "decode provider schemas")
+	}
+	if !strings.HasPrefix(doc.FormatVersion, "1.") {
+		return nil, errors.Errorf("unsupported provider schemas format version %q", doc.FormatVersion)
+	}
+
+	out := make(ProviderSchemas, len(doc.ProviderSchemas))
+	for addr, ps := range doc.ProviderSchemas {
+		p := &Provider{
+			Resources:   make(map[string]*hcler.Schema, len(ps.ResourceSchemas)),
+			DataSources: make(map[string]*hcler.Schema, len(ps.DataSourceSchemas)),
+		}
+		var err error
+		if ps.Provider != nil {
+			if p.Schema, err = ps.Provider.Block.schema(); err != nil {
+				return nil, errors.Wrapf(err, "provider %s", addr)
+			}
+		}
+		for typ, s := range ps.ResourceSchemas {
+			if p.Resources[typ], err = s.Block.schema(); err != nil {
+				return nil, errors.Wrapf(err, "resource %s", typ)
+			}
+			addMetaArguments(p.Resources[typ], true)
+		}
+		for typ, s := range ps.DataSourceSchemas {
+			if p.DataSources[typ], err = s.Block.schema(); err != nil {
+				return nil, errors.Wrapf(err, "data source %s", typ)
+			}
+			addMetaArguments(p.DataSources[typ], false)
+		}
+		out[addr] = p
+	}
+	return out, nil
+}
+
+// Resource returns the schema of the given resource type.
+func (ps ProviderSchemas) Resource(typ string) (*hcler.Schema, bool) {
+	for _, p := range ps {
+		if s, ok := p.Resources[typ]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// DataSource returns the schema of the given data source type.
+func (ps ProviderSchemas) DataSource(typ string) (*hcler.Schema, bool) {
+	for _, p := range ps {
+		if s, ok := p.DataSources[typ]; ok {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// ResourceBlock builds a `resource "typ" "name"` block from the given
+// generic body: nested block types are wrapped according to the schema
+// and the body is validated, see hcler.ApplySchema.
+func (ps ProviderSchemas) ResourceBlock(typ, name string, body interface{}) (hcler.Block, error) {
+	s, ok := ps.Resource(typ)
+	if !ok {
+		return hcler.Block{}, errors.Errorf("unknown resource type %q", typ)
+	}
+	return schemaBlock(s, typ, name, body)
+}
+
+// DataSourceBlock builds a `data "typ" "name"` block, see ResourceBlock.
+func (ps ProviderSchemas) DataSourceBlock(typ, name string, body interface{}) (hcler.Block, error) {
+	s, ok := ps.DataSource(typ)
+	if !ok {
+		return hcler.Block{}, errors.Errorf("unknown data source type %q", typ)
+	}
+	return schemaBlock(s, typ, name, body)
+}
+
+func schemaBlock(s *hcler.Schema, typ, name string, body interface{}) (hcler.Block, error) {
+	out, err := hcler.ApplySchema(body, s)
+	if err != nil {
+		return hcler.Block{}, errors.Wrapf(err, "%s.%s", typ, name)
+	}
+	return hcler.Block{Labels: []string{typ, name}, Body: out}, nil
+}
+
+// addMetaArguments adds the arguments and blocks Terraform accepts in
+// every resource or data source.
+func addMetaArguments(s *hcler.Schema, resource bool) {
+	for _, name := range []string{"count", "for_each", "depends_on", "provider"} {
+		s.Attributes[name] = hcler.AttributeSchema{Type: cty.DynamicPseudoType}
+	}
+	s.Blocks["lifecycle"] = hcler.BlockSchema{MaxItems: 1}
+	if resource {
+		s.Blocks["provisioner"] = hcler.BlockSchema{Labels: []string{"type"}}
+		s.Blocks["connection"] = hcler.BlockSchema{MaxItems: 1}
+	}
+}
+
+type schemaJSON struct {
+	Block *blockJSON `json:"block"`
+}
+
+type blockJSON struct {
+	Attributes map[string]*attributeJSON `json:"attributes"`
+	BlockTypes map[string]*struct {
+		NestingMode string     `json:"nesting_mode"`
+		Block       *blockJSON `json:"block"`
+		MinItems    int        `json:"min_items"`
+		MaxItems    int        `json:"max_items"`
+	} `json:"block_types"`
+}
+
+type attributeJSON struct {
+	Type       json.RawMessage `json:"type"`
+	NestedType *struct {
+		Attributes  map[string]*attributeJSON `json:"attributes"`
+		NestingMode string                    `json:"nesting_mode"`
+	} `json:"nested_type"`
+	Required bool `json:"required"`
+	Optional bool `json:"optional"`
+	Computed bool `json:"computed"`
+}
+
+// computedOnly reports whether the attribute is only set by the provider.
+func (a *attributeJSON) computedOnly() bool {
+	return a.Computed && !a.Optional && !a.Required
+}
+
+func (b *blockJSON) schema() (*hcler.Schema, error) {
+	s := &hcler.Schema{
+		Attributes: map[string]hcler.AttributeSchema{},
+		Blocks:     map[string]hcler.BlockSchema{},
+	}
+	if b == nil {
+		return s, nil
+	}
+	for name, a := range b.Attributes {
+		t, err := a.ctyType()
+		if err != nil {
+			return nil, errors.Wrapf(err, "attribute %q", name)
+		}
+		s.Attributes[name] = hcler.AttributeSchema{Type: t, Required: a.Required, Computed: a.computedOnly()}
+	}
+	for name, bt := range b.BlockTypes {
+		body, err := bt.Block.schema()
+		if err != nil {
+			return nil, errors.Wrapf(err, "block %q", name)
+		}
+		block := hcler.BlockSchema{MinItems: bt.MinItems, MaxItems: bt.MaxItems, Body: body}
+		switch bt.NestingMode {
+		case "single", "group":
+			block.MaxItems = 1
+		case "map":
+			block.Labels = []string{"key"}
+		case "list", "set":
+		default:
+			return nil, errors.Errorf("block %q: unsupported nesting mode %q", name, bt.NestingMode)
+		}
+		s.Blocks[name] = block
+	}
+	return s, nil
+}
+
+func (a *attributeJSON) ctyType() (cty.Type, error) {
+	if a.NestedType == nil {
+		var t cty.Type
+		if err := t.UnmarshalJSON(a.Type); err != nil {
+			return cty.NilType, err
+		}
+		return t, nil
+	}
+	attrs := make(map[string]cty.Type, len(a.NestedType.Attributes))
+	var optional []string
+	for name, nested := range a.NestedType.Attributes {
+		if nested.computedOnly() {
+			// Left out so setting it is reported as unsupported.
+			continue
+		}
+		t, err := nested.ctyType()
+		if err != nil {
+			return cty.NilType, errors.Wrapf(err, "attribute %q", name)
+		}
+		attrs[name] = t
+		if !nested.Required {
+			optional = append(optional, name)
+		}
+	}
+	obj := cty.ObjectWithOptionalAttrs(attrs, optional)
+	switch a.NestedType.NestingMode {
+	case "single":
+		return obj, nil
+	case "list":
+		return cty.List(obj), nil
+	case "set":
+		return cty.Set(obj), nil
+	case "map":
+		return cty.Map(obj), nil
+	default:
+		return cty.NilType, errors.Errorf("unsupported nesting mode %q", a.NestedType.NestingMode)
+	}
+}
@@ -0,0 +1,165 @@
+package terraform_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/creack/hcler/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func loadSchemas(t *testing.T) terraform.ProviderSchemas {
+	t.Helper()
+
+	f, err := os.Open("testdata/providers-schema.json")
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	ps, err := terraform.LoadProviderSchemas(f)
+	require.NoError(t, err)
+	return ps
+}
+
+func TestLoadProviderSchemas(t *testing.T) {
+	ps := loadSchemas(t)
+
+	aws := ps["registry.terraform.io/hashicorp/aws"]
+	require.NotNil(t, aws)
+	assert.Equal(t, cty.String, aws.Schema.Attributes["region"].Type)
+
+	s, ok := ps.Resource("aws_instance")
+	require.True(t, ok)
+	assert.Equal(t, hcler.AttributeSchema{Type: cty.String, Required: true}, s.Attributes["ami"])
+	assert.Equal(t, cty.Map(cty.String), s.Attributes["tags"].Type)
+	assert.True(t, s.Attributes["arn"].Computed)
+	assert.False(t, s.Attributes["id"].Computed)
+	assert.Equal(t, 1, s.Blocks["root_block_device"].MaxItems)
+	assert.Equal(t, 1, s.Blocks["timeouts"].MaxItems)
+	assert.Contains(t, s.Attributes, "for_each")
+	assert.Contains(t, s.Blocks, "lifecycle")
+
+	s, ok = ps.Resource("aws_lb_listener")
+	require.True(t, ok)
+	assert.Equal(t, []string{"key"}, s.Blocks["default_action"].Labels)
+	assert.True(t, s.Attributes["mutual_authentication"].Type.IsObjectType())
+
+	s, ok = ps.DataSource("aws_ami")
+	require.True(t, ok)
+	assert.NotContains(t, s.Blocks, "provisioner")
+
+	_, ok = ps.Resource("aws_ami")
+	assert.False(t, ok)
+}
+
+func TestResourceBlock(t *testing.T) {
+	ps := loadSchemas(t)
+
+	web, err := ps.ResourceBlock("aws_instance", "web", hcler.Map{
+		"ami":               expr.Ref("data", "aws_ami", "ubuntu", "id"),
+		"tags":              hcler.Map{"Name": "web"},
+		"count":             2,
+		"root_block_device": hcler.Map{"volume_size": 20},
+		"ebs_block_device": hcler.List{
+			hcler.Map{"device_name": "/dev/sdb"},
+			hcler.Map{"device_name": "/dev/sdc", "volume_size": 100},
+		},
+	})
+	require.NoError(t, err)
+	ami, err := ps.DataSourceBlock("aws_ami", "ubuntu", hcler.Map{
+		"most_recent": true,
+		"filter":      hcler.Map{"name": "name", "values": hcler.List{"ubuntu-*"}},
+	})
+	require.NoError(t, err)
+
+	got, err := hcler.EncodeBody(hcler.Map{"resource": web, "data": ami})
+	require.NoError(t, err)
+	assert.Equal(t, `data "aws_ami" "ubuntu" {
+  most_recent = "1"
+  filter {
+    name   = "name"
+    values = ["ubuntu-*"]
+  }
+}
+resource "aws_instance" "web" {
+  ami   = data.aws_ami.ubuntu.id
+  count = 2
+  tags = {
+    Name = "web"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+  ebs_block_device {
+    device_name = "/dev/sdc"
+    volume_size = 100
+  }
+  root_block_device {
+    volume_size = 20
+  }
+}
+`, got)
+
+	lb, err := ps.ResourceBlock("aws_lb_listener", "front", hcler.Map{
+		"mutual_authentication": hcler.Map{"mode": "verify"},
+		"default_action":        hcler.Map{"forward": hcler.Map{"type": "forward"}},
+	})
+	require.NoError(t, err)
+	got, err = hcler.EncodeBody(hcler.Map{"resource": lb})
+	require.NoError(t, err)
+	assert.Contains(t, got, "  default_action \"forward\" {\n    type = \"forward\"\n  }\n")
+	assert.Contains(t, got, "  mutual_authentication = {\n    mode = \"verify\"\n  }\n")
+}
+
+func TestResourceBlockError(t *testing.T) {
+	ps := loadSchemas(t)
+
+	_, err := ps.ResourceBlock("aws_instance", "web", hcler.Map{
+		"ami":               1.5,
+		"instance_typ":      "t3.micro",
+		"root_block_device": hcler.List{hcler.Map{}, hcler.Map{}},
+		"ebs_block_device":  hcler.Map{"volume_size": 1},
+		"tags":              hcler.List{"a"},
+		"arn":               "arn:aws:ec2:::instance/i-1",
+		"id":                "i-1",
+	})
+	require.Error(t, err)
+	for _, violation := range []string{
+		"ebs_block_device.device_name: missing required argument",
+		"instance_typ: unsupported argument or block",
+		"root_block_device: at most 1 block(s) allowed, got 2",
+		"tags: invalid value: map of string required",
+		"arn: unconfigurable attribute",
+	} {
+		assert.Contains(t, err.Error(), violation)
+	}
+	assert.True(t, strings.HasPrefix(err.Error(), "aws_instance.web: schema validation: "), err.Error())
+
+	_, err = ps.ResourceBlock("aws_lb_listener", "front", hcler.Map{
+		"mutual_authentication": hcler.Map{"mode": "verify", "unknown": 1},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "default_action: at least 1 block(s) required, got 0")
+	assert.Contains(t, err.Error(), "mutual_authentication: unsupported attribute unknown")
+
+	_, err = ps.ResourceBlock("aws_lb_listener", "front", hcler.Map{
+		"mutual_authentication": hcler.Map{"mode": "verify", "trust_store_name": "ts"},
+		"default_action":        hcler.Map{"forward": hcler.Map{"type": "forward"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mutual_authentication: unsupported attribute trust_store_name")
+
+	_, err = ps.ResourceBlock("aws_unknown", "x", nil)
+	assert.EqualError(t, err, `unknown resource type "aws_unknown"`)
+	_, err = ps.DataSourceBlock("aws_unknown", "x", nil)
+	assert.Error(t, err)
+
+	for _, doc := range []string{`{`, `{"format_version": "2.0"}`, `{"format_version": "1.0", "provider_schemas": {"p": {"resource_schemas": {"r": {"block": {"attributes": {"a": {"type": "strin"}}}}}}}}`} {
+		_, err := terraform.LoadProviderSchemas(strings.NewReader(doc))
+		assert.Error(t, err, doc)
+	}
+}
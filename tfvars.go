@@ -0,0 +1,82 @@
+package hcler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// WriteTFVars writes the given variables as a terraform.tfvars file:
+// one attribute per variable, sorted by name. Values must be literals
+// convertible with ToCty; expressions are rejected as tfvars files
+// can't reference or call anything.
+func WriteTFVars(w io.Writer, vars map[string]interface{}) error {
+	values, names, err := tfvarsValues(vars)
+	if err != nil {
+		return err
+	}
+	f := hclwrite.NewEmptyFile()
+	for _, name := range names {
+		f.Body().SetAttributeValue(name, values[name])
+	}
+	_, err = w.Write(hclwrite.Format(f.Bytes()))
+	return err
+}
+
+// WriteTFVarsJSON writes the given variables as a .auto.tfvars.json file.
+func WriteTFVarsJSON(w io.Writer, vars map[string]interface{}) error {
+	values, _, err := tfvarsValues(vars)
+	if err != nil {
+		return err
+	}
+	// Untyped nulls would be marshaled with their dynamic type wrapper.
+	obj, err := cty.Transform(cty.ObjectVal(values), func(_ cty.Path, v cty.Value) (cty.Value, error) {
+		if v.IsNull() && v.Type() == cty.DynamicPseudoType {
+			return cty.NullVal(cty.String), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := ctyjson.Marshal(obj, obj.Type())
+	if err != nil {
+		return errors.Wrap(err, "marshal tfvars")
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// tfvarsValues converts the given variables and returns them with their sorted names.
+func tfvarsValues(vars map[string]interface{}) (map[string]cty.Value, []string, error) {
+	names := make([]string, 0, len(vars))
+	values := make(map[string]cty.Value, len(vars))
+	for name, v := range vars {
+		if !IsIdentifier(name) {
+			return nil, nil, errors.Wrapf(ErrInvalidKey, "variable %q", name)
+		}
+		val, err := ToCty(v)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "variable %q", name)
+		}
+		val, _ = val.UnmarkDeep()
+		if !val.IsWhollyKnown() {
+			return nil, nil, errors.Wrapf(ErrUnknownValue, "variable %q", name)
+		}
+		names = append(names, name)
+		values[name] = val
+	}
+	sort.Strings(names)
+	return values, names, nil
+}
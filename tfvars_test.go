@@ -0,0 +1,73 @@
+package hcler_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/creack/hcler"
+	"github.com/creack/hcler/expr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var tfvars = map[string]interface{}{
+	"region":  "us-east-1",
+	"count":   3,
+	"enabled": true,
+	"zones":   []string{"a", "b"},
+	"tags":    hcler.Map{"team": "infra", "cost-center": "42"},
+	"prefix":  "${var}",
+	"unset":   nil,
+}
+
+func TestWriteTFVars(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, hcler.WriteTFVars(&buf, tfvars))
+	assert.Equal(t, `count   = 3
+enabled = true
+prefix  = "$${var}"
+region  = "us-east-1"
+tags = {
+  cost-center = "42"
+  team        = "infra"
+}
+unset = null
+zones = ["a", "b"]
+`, buf.String())
+}
+
+func TestWriteTFVarsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, hcler.WriteTFVarsJSON(&buf, tfvars))
+	assert.Equal(t, `{
+  "count": 3,
+  "enabled": true,
+  "prefix": "${var}",
+  "region": "us-east-1",
+  "tags": {
+    "cost-center": "42",
+    "team": "infra"
+  },
+  "unset": null,
+  "zones": [
+    "a",
+    "b"
+  ]
+}
+`, buf.String())
+}
+
+func TestWriteTFVarsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := hcler.WriteTFVars(&buf, map[string]interface{}{"a b": 1})
+	assert.Equal(t, hcler.ErrInvalidKey, errors.Cause(err))
+
+	err = hcler.WriteTFVars(&buf, map[string]interface{}{"region": expr.Ref("var", "region")})
+	assert.Error(t, err)
+
+	err = hcler.WriteTFVarsJSON(&buf, map[string]interface{}{"id": cty.UnknownVal(cty.String)})
+	assert.Equal(t, hcler.ErrUnknownValue, errors.Cause(err))
+	assert.Empty(t, buf.String())
+}
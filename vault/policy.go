@@ -0,0 +1,139 @@
+// Package vault builds Vault ACL policy documents with hcler.
+package vault
+
+import (
+	"sort"
+	"time"
+
+	"github.com/creack/hcler"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCapability is returned when a path lists an unknown capability.
+var ErrInvalidCapability = errors.New("invalid capability")
+
+// Capability is a Vault path capability.
+type Capability string
+
+// Known capabilities.
+const (
+	Create    Capability = "create"
+	Read      Capability = "read"
+	Update    Capability = "update"
+	Patch     Capability = "patch"
+	Delete    Capability = "delete"
+	List      Capability = "list"
+	Sudo      Capability = "sudo"
+	Deny      Capability = "deny"
+	Subscribe Capability = "subscribe"
+	Recover   Capability = "recover"
+)
+
+var capabilities = map[Capability]bool{
+	Create: true, Read: true, Update: true, Patch: true, Delete: true,
+	List: true, Sudo: true, Deny: true, Subscribe: true, Recover: true,
+}
+
+// Valid reports whether c is a known capability.
+func (c Capability) Valid() bool { return capabilities[c] }
+
+// Policy is a Vault ACL policy document.
+type Policy struct {
+	Paths []Path
+}
+
+// Path is a `path "pattern"` rule, i.e. Path{Pattern: "secret/*", Capabilities: []Capability{Read}}.
+type Path struct {
+	Pattern            string
+	Capabilities       []Capability
+	RequiredParameters []string
+	AllowedParameters  map[string][]string
+	DeniedParameters   map[string][]string
+	MinWrappingTTL     time.Duration
+	MaxWrappingTTL     time.Duration
+}
+
+// Body builds the policy document body.
+func (p Policy) Body() (hcler.OrderedMap, error) {
+	if len(p.Paths) == 0 {
+		return nil, errors.New("at least one path is required")
+	}
+	paths := make(hcler.Blocks, 0, len(p.Paths))
+	for _, path := range p.Paths {
+		b, err := path.Block()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, b)
+	}
+	return hcler.OrderedMap{{Key: "path", Value: paths}}, nil
+}
+
+// Encode renders the policy document.
+func (p Policy) Encode() (string, error) {
+	body, err := p.Body()
+	if err != nil {
+		return "", err
+	}
+	return hcler.EncodeBody(body)
+}
+
+// Block builds the `path` block.
+func (p Path) Block() (hcler.Block, error) {
+	if p.Pattern == "" {
+		return hcler.Block{}, errors.New("path pattern is required")
+	}
+	if len(p.Capabilities) == 0 {
+		return hcler.Block{}, errors.Errorf("path %q: at least one capability is required", p.Pattern)
+	}
+	caps := make(hcler.List, 0, len(p.Capabilities))
+	for _, c := range p.Capabilities {
+		if !c.Valid() {
+			return hcler.Block{}, errors.Wrapf(ErrInvalidCapability, "path %q: %q", p.Pattern, string(c))
+		}
+		caps = append(caps, string(c))
+	}
+	if p.MinWrappingTTL != 0 && p.MaxWrappingTTL != 0 && p.MinWrappingTTL > p.MaxWrappingTTL {
+		return hcler.Block{}, errors.Errorf("path %q: min_wrapping_ttl is greater than max_wrapping_ttl", p.Pattern)
+	}
+
+	body := hcler.OrderedMap{{Key: "capabilities", Value: caps}}
+	if len(p.RequiredParameters) > 0 {
+		body.Set("required_parameters", stringList(p.RequiredParameters))
+	}
+	setParameters(&body, "allowed_parameters", p.AllowedParameters)
+	setParameters(&body, "denied_parameters", p.DeniedParameters)
+	if p.MinWrappingTTL != 0 {
+		body.Set("min_wrapping_ttl", p.MinWrappingTTL.String())
+	}
+	if p.MaxWrappingTTL != 0 {
+		body.Set("max_wrapping_ttl", p.MaxWrappingTTL.String())
+	}
+	return hcler.Block{Labels: []string{p.Pattern}, Body: body}, nil
+}
+
+// setParameters sets the given parameter constraints, a nil value
+// list allows or denies any value.
+func setParameters(body *hcler.OrderedMap, k string, params map[string][]string) {
+	if len(params) == 0 {
+		return
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make(hcler.OrderedMap, 0, len(params))
+	for _, name := range names {
+		out = append(out, hcler.KeyValue{Key: name, Value: stringList(params[name])})
+	}
+	body.Set(k, out)
+}
+
+func stringList(l []string) hcler.List {
+	out := make(hcler.List, 0, len(l))
+	for _, s := range l {
+		out = append(out, s)
+	}
+	return out
+}
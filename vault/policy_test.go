@@ -0,0 +1,60 @@
+package vault_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creack/hcler/vault"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	p := vault.Policy{Paths: []vault.Path{
+		{Pattern: "secret/data/team/*", Capabilities: []vault.Capability{vault.Read, vault.List}},
+		{
+			Pattern:            "auth/token/create",
+			Capabilities:       []vault.Capability{vault.Update},
+			RequiredParameters: []string{"ttl"},
+			AllowedParameters:  map[string][]string{"ttl": {"1h", "2h"}, "*": nil},
+			MaxWrappingTTL:     time.Hour,
+		},
+	}}
+	out, err := p.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, `path "secret/data/team/*" {
+  capabilities = ["read", "list"]
+}
+path "auth/token/create" {
+  capabilities        = ["update"]
+  required_parameters = ["ttl"]
+  allowed_parameters = {
+    "*" = []
+    ttl = ["1h", "2h"]
+  }
+  max_wrapping_ttl = "1h0m0s"
+}
+`, out)
+}
+
+func TestPolicyErrors(t *testing.T) {
+	_, err := vault.Policy{}.Encode()
+	assert.EqualError(t, err, "at least one path is required")
+
+	_, err = vault.Policy{Paths: []vault.Path{{Pattern: "secret/*"}}}.Encode()
+	assert.EqualError(t, err, `path "secret/*": at least one capability is required`)
+
+	_, err = vault.Policy{Paths: []vault.Path{{Pattern: "secret/*", Capabilities: []vault.Capability{"write"}}}}.Encode()
+	require.Error(t, err)
+	assert.Equal(t, vault.ErrInvalidCapability, errors.Cause(err))
+	assert.EqualError(t, err, `path "secret/*": "write": invalid capability`)
+
+	_, err = vault.Policy{Paths: []vault.Path{{
+		Pattern:        "secret/*",
+		Capabilities:   []vault.Capability{vault.Read},
+		MinWrappingTTL: time.Hour,
+		MaxWrappingTTL: time.Minute,
+	}}}.Encode()
+	assert.EqualError(t, err, `path "secret/*": min_wrapping_ttl is greater than max_wrapping_ttl`)
+}